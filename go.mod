@@ -3,6 +3,7 @@ module github.com/kenita8/xlcmd
 go 1.22.5
 
 require (
+	github.com/bmatcuk/doublestar/v4 v4.6.1
 	github.com/dlclark/regexp2 v1.11.4
 	github.com/kenita8/errors v0.0.1
 	github.com/stretchr/testify v1.9.0
@@ -32,6 +32,7 @@ type Excel interface {
 	Open(filename string) error
 	GetCellValue(sheet string, col int, row int) (string, error)
 	SetCellValue(value string, sheet string, col int, row int, opt *excel.CellOption) error
+	AutoFit(sheet string, left int, right int, maxWidth float64) error
 	CoordinatesToCellName(col int, row int, abs ...bool) (string, error)
 	CellNameToCoordinates(cell string) (int, int, error)
 	Save() error
@@ -65,6 +66,10 @@ func (c *Cellset) cellSet(config config.Config) error {
 	}
 	sheet := config.SheetName()
 	output := config.XlsxFilename()
+	maxWidth, err := config.MaxWidth()
+	if err != nil {
+		return err
+	}
 
 	left, top, err := c.excel.CellNameToCoordinates(topLeft)
 	if err != nil {
@@ -96,6 +101,12 @@ func (c *Cellset) cellSet(config config.Config) error {
 			}
 		}
 	}
+	if config.AutoFit() {
+		err = c.excel.AutoFit(sheet, left, right, maxWidth)
+		if err != nil {
+			return err
+		}
+	}
 
 	err = c.excel.Save()
 	if err != nil {
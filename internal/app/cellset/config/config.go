@@ -18,6 +18,7 @@ import (
 
 	"github.com/dlclark/regexp2"
 	"github.com/kenita8/xlcmd/internal/app/cellset/param"
+	"github.com/kenita8/xlcmd/internal/pkg/excel"
 	"go.uber.org/zap"
 )
 
@@ -26,6 +27,8 @@ type Config interface {
 	SheetName() string
 	ReplaceConfig() (Replacer, error)
 	Range() (string, string, error)
+	AutoFit() bool
+	MaxWidth() (float64, error)
 }
 
 type config struct {
@@ -108,3 +111,16 @@ func (c *config) SheetName() string {
 func (c *config) XlsxFilename() string {
 	return c.param.XlsxFilename()
 }
+
+func (c *config) AutoFit() bool {
+	return c.param.AutoFit()
+}
+
+func (c *config) MaxWidth() (float64, error) {
+	maxWidth := c.param.MaxWidth()
+	err := excel.CheckMaxWidth(maxWidth)
+	if err != nil {
+		return 0, err
+	}
+	return maxWidth, nil
+}
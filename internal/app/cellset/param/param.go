@@ -16,6 +16,7 @@ package param
 import (
 	"flag"
 
+	"github.com/kenita8/xlcmd/internal/pkg/excel"
 	"go.uber.org/zap"
 )
 
@@ -27,6 +28,8 @@ type Param interface {
 	Text() (string, bool)
 	ReplacePattern() (string, bool)
 	Replacement() (string, bool)
+	AutoFit() bool
+	MaxWidth() float64
 }
 
 type param struct {
@@ -40,6 +43,8 @@ type param struct {
 	patternSet     bool
 	replacement    string
 	replacementSet bool
+	autoFit        bool
+	maxWidth       float64
 }
 
 func NewParam(log *zap.Logger) Param {
@@ -53,6 +58,8 @@ func (p *param) Parse() {
 	text := flag.String("text", "", "Specify the string to be stored in the cell.")
 	pattern := flag.String("pattern", "", "Set the pattern to replace in cell values.")
 	replacement := flag.String("replacement", "", "Set the string to replace with.")
+	autoFit := flag.Bool("autofit", false, "Fit the width of the columns of the range to the text of their cells.")
+	maxWidth := flag.Float64("max-width", excel.DefaultMaxWidth, "Cap the widths set by --autofit, in characters.")
 
 	flag.Parse()
 
@@ -62,6 +69,8 @@ func (p *param) Parse() {
 	p.text = *text
 	p.pattern = *pattern
 	p.replacement = *replacement
+	p.autoFit = *autoFit
+	p.maxWidth = *maxWidth
 
 	flag.Visit(func(f *flag.Flag) {
 		if f.Name == "text" {
@@ -97,3 +106,11 @@ func (p *param) ReplacePattern() (string, bool) {
 func (p *param) Replacement() (string, bool) {
 	return p.replacement, p.replacementSet
 }
+
+func (p *param) AutoFit() bool {
+	return p.autoFit
+}
+
+func (p *param) MaxWidth() float64 {
+	return p.maxWidth
+}
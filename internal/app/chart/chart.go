@@ -29,7 +29,7 @@ type Chart struct {
 }
 
 type Excel interface {
-	Open(filename string) error
+	OpenWithTemplate(filename string, template string) error
 	AddChart(chart *excelize.ExcelizeChartOption) error
 	Save() error
 	Close()
@@ -58,7 +58,7 @@ func (c *Chart) generateChart(config config.Config) error {
 	}
 	output := config.XlsxFilename()
 
-	err = c.excel.Open(output)
+	err = c.excel.OpenWithTemplate(output, config.Template())
 	if err != nil {
 		return err
 	}
@@ -26,6 +26,7 @@ import (
 type Config interface {
 	ExelizeChartOption() ([]*excelize.ExcelizeChartOption, error)
 	XlsxFilename() string
+	Template() string
 }
 
 type config struct {
@@ -59,3 +60,7 @@ func (c *config) ExelizeChartOption() ([]*excelize.ExcelizeChartOption, error) {
 func (c *config) XlsxFilename() string {
 	return c.param.XlsxFilename()
 }
+
+func (c *config) Template() string {
+	return c.param.Template()
+}
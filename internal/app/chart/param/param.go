@@ -23,12 +23,14 @@ type Param interface {
 	Parse()
 	ConfigFilename() string
 	XlsxFilename() string
+	Template() string
 }
 
 type param struct {
 	log            *zap.Logger
 	configFilename string
 	xlsxFilename   string
+	template       string
 }
 
 func NewParam(log *zap.Logger) Param {
@@ -38,9 +40,11 @@ func NewParam(log *zap.Logger) Param {
 func (p *param) Parse() {
 	configFilename := flag.String("config", "chart.yml", "Set Excel chart configuration file.")
 	xlsxFilename := flag.String("xlsx", "output.xlsx", "Set output Excel file name.")
+	template := flag.String("template", "", "Start the output as a copy of this workbook when it does not exist yet, keeping its styles, images, sheets and formulas, e.g. report.xltx.")
 	flag.Parse()
 	p.configFilename = *configFilename
 	p.xlsxFilename = *xlsxFilename
+	p.template = *template
 }
 
 func (p *param) ConfigFilename() string {
@@ -50,3 +54,7 @@ func (p *param) ConfigFilename() string {
 func (p *param) XlsxFilename() string {
 	return p.xlsxFilename
 }
+
+func (p *param) Template() string {
+	return p.template
+}
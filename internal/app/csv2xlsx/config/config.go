@@ -17,18 +17,69 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
 
 	"github.com/kenita8/xlcmd/internal/app/csv2xlsx/param"
 	"github.com/kenita8/xlcmd/internal/pkg/excel"
+	"github.com/kenita8/xlcmd/internal/pkg/file/archive"
+	"github.com/kenita8/xlcmd/internal/pkg/file/fixed"
+	"github.com/kenita8/xlcmd/internal/pkg/file/json"
+	"github.com/kenita8/xlcmd/internal/pkg/file/logfile"
+	"github.com/kenita8/xlcmd/internal/pkg/file/perfmon"
 	"go.uber.org/zap"
 )
 
 type Config interface {
-	InputFiles() ([]string, string, error)
-	CellOption() *excel.CellOption
+	InputFiles() ([]Input, error)
+	InputOption() (*InputOption, error)
+	CellOption() (*excel.CellOption, error)
+	SheetOption() (*excel.SheetOption, error)
+	Jobs() (int, error)
+	ErrorFile() (string, error)
 	XlsxFilename() string
+	Template() string
+}
+
+const (
+	// StdinName is the --input value for the standard input.
+	StdinName  = "-"
+	stdinSheet = "stdin"
+)
+
+// Input is a file to import and the sheet it is written to. A member of an
+// archive also has the archive path and the member name, and the standard
+// input has the format given for it.
+type Input struct {
+	Pathname string
+	Sheet    string
+	Archive  string
+	Member   string
+	Format   string
+	// StartCell, Header, NumberFormats and Option come from a manifest.
+	// StartCell is empty and the others are nil when the flags apply.
+	StartCell string
+	Header    *bool
+	// NumberFormats are added to those of the flags.
+	NumberFormats []excel.NumberFormat
+	Option        *InputOption
+	// Stacked puts the input below the one before it on the same sheet.
+	Stacked bool
+}
+
+// InputOption configures the readers of the input files.
+type InputOption struct {
+	Encoding       string
+	JsonArrays     json.ArrayPolicy
+	LogPattern     *regexp.Regexp
+	UnmatchedSheet bool
+	Widths         *fixed.Spec
+	Csv            *CsvOption
+	// Perfmon reads csv and tsv inputs as Performance Monitor logs, with the
+	// host left out of the counter paths when StripHost is set.
+	Perfmon   bool
+	StripHost bool
 }
 
 type config struct {
@@ -40,61 +91,398 @@ func NewConfig(param param.Param, log *zap.Logger) Config {
 	return &config{param: param, log: log}
 }
 
-func (c *config) InputFiles() ([]string, string, error) {
-	encoding := c.param.Encoding()
-	input := c.param.Input()
+func (c *config) InputFiles() ([]Input, error) {
+	if len(c.param.Manifest()) > 0 {
+		return c.manifestInputs()
+	}
+	template := c.param.SheetName()
+	err := checkSheetNameTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	glob, err := newGlobFilter(c.param.Include(), c.param.Exclude())
+	if err != nil {
+		return nil, err
+	}
+	order := SortOrder(strings.ToLower(c.param.Sort()))
+	if !slices.Contains([]SortOrder{SortNone, SortName, SortMtime, SortNatural}, order) {
+		return nil, ErrInvalidSort.Details("sort", c.param.Sort())
+	}
+	inputs := []Input{}
+	stdin := false
+	for _, pathname := range c.param.Inputs() {
+		if pathname == StdinName {
+			if stdin {
+				return nil, ErrStdinTwice
+			}
+			stdin = true
+		}
+		found, err := c.inputPaths(pathname, glob)
+		if err != nil {
+			return nil, err
+		}
+		sortInputs(found, order)
+		inputs = append(inputs, found...)
+	}
+	nameSheets(template, inputs, c.reservedSheets())
+	for _, input := range inputs {
+		c.log.Info("input", zap.String("path", input.Pathname), zap.String("sheet", input.Sheet))
+	}
+	return inputs, nil
+}
+
+func (c *config) InputOption() (*InputOption, error) {
+	arrays := json.ArrayPolicy(strings.ToLower(c.param.JsonArrays()))
+	if !slices.Contains([]json.ArrayPolicy{json.ArrayJoin, json.ArrayExplode, json.ArrayJson}, arrays) {
+		return nil, ErrInvalidJsonArrays.Details("json-arrays", c.param.JsonArrays())
+	}
+	var pattern *regexp.Regexp
+	var err error
+	if len(c.param.LogPattern()) > 0 {
+		pattern, err = logfile.Compile(c.param.LogPattern())
+		if err != nil {
+			return nil, err
+		}
+	}
+	var widths *fixed.Spec
+	if len(c.param.Widths()) > 0 {
+		if pattern != nil {
+			return nil, ErrWidthsWithLogPattern
+		}
+		widths, err = c.widthsSpec()
+		if err != nil {
+			return nil, err
+		}
+	}
+	unmatched := strings.ToLower(c.param.Unmatched())
+	if unmatched != "drop" && unmatched != "sheet" {
+		return nil, ErrInvalidUnmatched.Details("unmatched", c.param.Unmatched())
+	}
+	csvOpt, err := c.csvOption()
+	if err != nil {
+		return nil, err
+	}
+	isPerfmon, err := c.perfmon()
+	if err != nil {
+		return nil, err
+	}
+	return &InputOption{
+		Encoding:       c.param.Encoding(),
+		JsonArrays:     arrays,
+		LogPattern:     pattern,
+		UnmatchedSheet: unmatched == "sheet",
+		Widths:         widths,
+		Csv:            csvOpt,
+		Perfmon:        isPerfmon,
+		StripHost:      c.param.StripHost(),
+	}, nil
+}
+
+// perfmon tells whether --preset is perfmon, the only preset so far.
+func (c *config) perfmon() (bool, error) {
+	preset := strings.ToLower(c.param.Preset())
+	if len(preset) > 0 && preset != "perfmon" {
+		return false, ErrInvalidPreset.Details("preset", c.param.Preset())
+	}
+	if len(preset) <= 0 && c.param.StripHost() {
+		return false, ErrStripHostWithoutPreset
+	}
+	return len(preset) > 0, nil
+}
+
+// header tells whether the inputs have a header row, as a Performance Monitor
+// log always does.
+func (c *config) header() bool {
+	return c.param.Header() || strings.EqualFold(c.param.Preset(), "perfmon")
+}
+
+// widthsSpec reads --widths, either a list of widths or a YAML spec file.
+func (c *config) widthsSpec() (*fixed.Spec, error) {
+	widths := c.param.Widths()
+	ext := strings.ToLower(filepath.Ext(widths))
+	if ext == ".yml" || ext == ".yaml" {
+		return fixed.LoadSpec(widths)
+	}
+	return fixed.ParseWidths(widths)
+}
+
+// inputPaths finds the inputs of one --input value, "-" being the standard
+// input. A file named directly skips the glob filter.
+func (c *config) inputPaths(input string, glob *globFilter) ([]Input, error) {
+	if input == StdinName {
+		return c.stdinInput()
+	}
 	depth := c.param.Depth()
 	exts := strings.Split(strings.ToLower(c.param.Extension()), ",")
 
 	input, err := filepath.Abs(input)
 	if err != nil {
-		return nil, "", ErrConvertAbsPath.Wrap(err)
+		return nil, ErrConvertAbsPath.Wrap(err)
 	}
 	input = filepath.Clean(input)
 	stat, err := os.Stat(input)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 	if !stat.IsDir() {
-		return []string{input}, encoding, nil
+		if archive.IsArchive(input) {
+			inputs, err := archiveInputs(input, exts, depth)
+			if err != nil {
+				return nil, err
+			}
+			// Like a directory, an archive must hold an input.
+			inputs = glob.filter(filepath.Dir(input), inputs)
+			if len(inputs) <= 0 {
+				return nil, ErrNotFoundInputFile
+			}
+			return inputs, nil
+		}
+		return []Input{{Pathname: input}}, nil
 	}
 
 	rootDepth := strings.Count(input, string(os.PathSeparator))
-	paths := []string{}
+	inputs := []Input{}
 	err = filepath.WalkDir(input, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		pathDepth := strings.Count(path, string(os.PathSeparator)) - rootDepth
 		if d.IsDir() {
-			if strings.Count(path, string(os.PathSeparator)) > rootDepth+depth {
+			if pathDepth > depth {
 				return fs.SkipDir
 			}
-		} else {
-			ext := strings.ToLower(filepath.Ext(path))
-			if len(ext) > 0 && slices.Contains(exts, ext[1:]) {
-				paths = append(paths, path)
+		} else if archive.IsArchive(path) {
+			members, err := archiveInputs(path, exts, depth-(pathDepth-1))
+			if err != nil {
+				return err
 			}
+			inputs = append(inputs, members...)
+		} else if hasExtension(path, exts) {
+			inputs = append(inputs, Input{Pathname: path})
 		}
 		return nil
 	})
 
 	if err != nil {
-		return nil, "", ErrWalkInputDir.Wrap(err)
+		return nil, ErrWalkInputDir.Wrap(err)
 	}
 
-	if len(paths) <= 0 {
-		return nil, "", ErrNotFoundInputFile
+	inputs = glob.filter(input, inputs)
+	if len(inputs) <= 0 {
+		return nil, ErrNotFoundInputFile
+	}
+
+	return inputs, nil
+}
+
+func (c *config) stdinInput() ([]Input, error) {
+	format := strings.ToLower(strings.TrimPrefix(c.param.Format(), "."))
+	if len(format) <= 0 {
+		return nil, ErrRequireFormat
+	}
+	sheet := c.param.Sheet()
+	if len(sheet) <= 0 {
+		sheet = stdinSheet
 	}
+	return []Input{{Pathname: StdinName, Sheet: sheet, Format: format}}, nil
+}
 
-	return paths, encoding, nil
+func hasExtension(path string, exts []string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return len(ext) > 0 && slices.Contains(exts, ext[1:])
 }
 
-func (c *config) CellOption() *excel.CellOption {
+// archiveInputs lists the members of an archive like the files of a directory.
+func archiveInputs(pathname string, exts []string, depth int) ([]Input, error) {
+	members, err := archive.Members(pathname)
+	if err != nil {
+		return nil, err
+	}
+	inputs := []Input{}
+	for _, member := range members {
+		if strings.Count(member, "/") > depth || !hasExtension(member, exts) {
+			continue
+		}
+		inputs = append(inputs, Input{
+			Pathname: archive.Join(pathname, member),
+			Archive:  pathname,
+			Member:   member,
+		})
+	}
+	return inputs, nil
+}
+
+func (c *config) CellOption() (*excel.CellOption, error) {
+	isPerfmon, err := c.perfmon()
+	if err != nil {
+		return nil, err
+	}
+	var layouts []string
+	if len(c.param.DateLayouts()) > 0 {
+		layouts = strings.Split(c.param.DateLayouts(), ";")
+		if isPerfmon && !slices.Contains(layouts, perfmon.TimestampLayout) {
+			layouts = append(layouts, perfmon.TimestampLayout)
+		}
+	}
+	number, err := c.numberLocale()
+	if err != nil {
+		return nil, err
+	}
+	policy := excel.ErrorPolicy(strings.ToLower(c.param.OnError()))
+	if !slices.Contains([]excel.ErrorPolicy{excel.OnErrorFail, excel.OnErrorSkip, excel.OnErrorReport}, policy) {
+		return nil, ErrInvalidOnError.Details("on-error", c.param.OnError())
+	}
+	columns, err := c.columns()
+	if err != nil {
+		return nil, err
+	}
+	where, err := c.where()
+	if err != nil {
+		return nil, err
+	}
+	formats, err := c.numberFormats()
+	if err != nil {
+		return nil, err
+	}
 	return &excel.CellOption{
 		DecimalPlaces: c.param.DecimalPlaces(),
+		Round:         c.param.Round(),
+		InferTypes:    c.param.InferTypes() || isPerfmon,
+		DateLayouts:   layouts,
+		Header:        c.header(),
+		Number:        number,
+		OnError:       policy,
+		Columns:       columns,
+		Where:         where,
+		NumberFormats: formats,
+	}, nil
+}
+
+// columns reads --columns, either a list of columns or a YAML column file.
+func (c *config) columns() (*excel.Columns, error) {
+	text := c.param.Columns()
+	if len(text) <= 0 {
+		return nil, nil
+	}
+	var columns *excel.Columns
+	var err error
+	ext := strings.ToLower(filepath.Ext(text))
+	if ext == ".yml" || ext == ".yaml" {
+		columns, err = excel.LoadColumns(text)
+	} else {
+		columns, err = excel.ParseColumns(text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !c.header() && columns.ByHeader() {
+		return nil, ErrColumnsWithoutHeader
+	}
+	return columns, nil
+}
+
+func (c *config) where() (*excel.Where, error) {
+	if len(c.param.Where()) <= 0 {
+		return nil, nil
+	}
+	where, err := excel.ParseWhere(c.param.Where())
+	if err != nil {
+		return nil, err
 	}
+	if !c.header() && where.ByHeader() {
+		return nil, ErrWhereWithoutHeader
+	}
+	return where, nil
+}
+
+func (c *config) numberFormats() ([]excel.NumberFormat, error) {
+	formats, err := parseNumberFormats(c.param.NumberFormats())
+	if err != nil {
+		return nil, err
+	}
+	err = checkNumberFormats(formats, c.header())
+	if err != nil {
+		return nil, err
+	}
+	return formats, nil
+}
+
+// checkNumberFormats fails when an input without a header has a format that
+// picks its column by header.
+func checkNumberFormats(formats []excel.NumberFormat, header bool) error {
+	for _, format := range formats {
+		if !header && format.ByHeader() {
+			return ErrNumberFormatWithoutHeader.Details("number-format", format.Column.String())
+		}
+	}
+	return nil
+}
+
+func parseNumberFormats(texts []string) ([]excel.NumberFormat, error) {
+	formats := []excel.NumberFormat{}
+	for _, text := range texts {
+		format, err := excel.ParseNumberFormat(text)
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+func (c *config) SheetOption() (*excel.SheetOption, error) {
+	mode := excel.PasteMode(strings.ToLower(c.param.Mode()))
+	if !slices.Contains([]excel.PasteMode{excel.PasteModeReplace, excel.PasteModeAppend, excel.PasteModeSkip}, mode) {
+		return nil, ErrInvalidMode.Details("mode", c.param.Mode())
+	}
+	split := excel.SplitPolicy(strings.ToLower(c.param.Split()))
+	if !slices.Contains([]excel.SplitPolicy{excel.SplitSheets, excel.SplitWorkbooks}, split) {
+		return nil, ErrInvalidSplit.Details("split", c.param.Split())
+	}
+	maxWidth := c.param.MaxWidth()
+	err := excel.CheckMaxWidth(maxWidth)
+	if err != nil {
+		return nil, err
+	}
+	return &excel.SheetOption{
+		HeaderStyle:  c.param.HeaderStyle(),
+		FreezeHeader: c.param.FreezeHeader(),
+		AutoFilter:   c.param.AutoFilter(),
+		TableStyle:   c.param.TableStyle(),
+		Mode:         mode,
+		SkipHeader:   c.param.SkipHeader(),
+		Split:        split,
+		StartCell:    c.param.StartCell(),
+		AutoFit:      c.param.AutoFit(),
+		MaxWidth:     maxWidth,
+	}, nil
+}
+
+func (c *config) Jobs() (int, error) {
+	jobs := c.param.Jobs()
+	if jobs < 1 {
+		return 0, ErrInvalidJobs.Details("jobs", jobs)
+	}
+	return jobs, nil
+}
+
+// ErrorFile returns the CSV file for rejected rows, or "" for the errors
+// sheet.
+func (c *config) ErrorFile() (string, error) {
+	pathname := c.param.ErrorFile()
+	if len(pathname) <= 0 {
+		return "", nil
+	}
+	if excel.ErrorPolicy(strings.ToLower(c.param.OnError())) != excel.OnErrorReport {
+		return "", ErrErrorFileWithoutReport
+	}
+	return pathname, nil
 }
 
 func (c *config) XlsxFilename() string {
 	return c.param.XlsxFilename()
 }
+
+func (c *config) Template() string {
+	return c.param.Template()
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/kenita8/xlcmd/internal/pkg/file/perfmon"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCellOptionPerfmon(t *testing.T) {
+	testcases := []struct {
+		preset        string
+		header        bool
+		dateLayouts   string
+		expectHeader  bool
+		expectLayouts []string
+	}{
+		{"", false, "", false, nil},
+		{"", true, "2006-01-02", true, []string{"2006-01-02"}},
+		{"perfmon", false, "", true, nil},
+		{"perfmon", true, "2006-01-02", true, []string{"2006-01-02", perfmon.TimestampLayout}},
+		{"perfmon", true, perfmon.TimestampLayout, true, []string{perfmon.TimestampLayout}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			p := newTestParam()
+			p.preset = tc.preset
+			p.header = tc.header
+			p.dateLayouts = tc.dateLayouts
+			c := &config{param: p, log: zap.NewNop()}
+			opt, err := c.CellOption()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectHeader, opt.Header)
+			assert.Equal(t, tc.expectLayouts, opt.DateLayouts)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// sniffDelimiter makes the delimiter of every csv input guessed.
+	sniffDelimiter = "auto"
+)
+
+// CsvOption is the dialect of csv and tsv inputs. A zero Delimiter keeps the
+// delimiter of the extension.
+type CsvOption struct {
+	Delimiter       rune
+	Sniff           bool
+	Comment         rune
+	LazyQuotes      bool
+	TrimSpace       bool
+	FieldsPerRecord int
+	SkipRows        int
+}
+
+func (c *config) csvOption() (*CsvOption, error) {
+	return newCsvOption(c.param.Delimiter(), c.param.Comment(), c.param.LazyQuotes(), c.param.TrimSpace(),
+		c.param.FieldsPerRecord(), c.param.SkipRows())
+}
+
+func newCsvOption(delimiter string, comment string, lazyQuotes bool, trimSpace bool, fieldsPerRecord int, skipRows int) (*CsvOption, error) {
+	opt := &CsvOption{
+		LazyQuotes:      lazyQuotes,
+		TrimSpace:       trimSpace,
+		FieldsPerRecord: fieldsPerRecord,
+		SkipRows:        skipRows,
+	}
+	if strings.EqualFold(delimiter, sniffDelimiter) {
+		opt.Sniff = true
+	} else if len(delimiter) > 0 {
+		r, ok := csvRune(delimiter)
+		if !ok {
+			return nil, ErrInvalidDelimiter.Details("delimiter", delimiter)
+		}
+		opt.Delimiter = r
+	}
+	if len(comment) > 0 {
+		r, ok := csvRune(comment)
+		if !ok || r == opt.Delimiter {
+			return nil, ErrInvalidComment.Details("comment", comment)
+		}
+		opt.Comment = r
+	}
+	if opt.SkipRows < 0 {
+		return nil, ErrInvalidSkipRows.Details("skip-rows", opt.SkipRows)
+	}
+	return opt, nil
+}
+
+// csvRune returns the single character of text. "tab" and `\t` stand for a
+// tab, which is hard to pass on a command line.
+func csvRune(text string) (rune, bool) {
+	if strings.EqualFold(text, "tab") || text == `\t` {
+		return '\t', true
+	}
+	r, size := utf8.DecodeRuneInString(text)
+	if size != len(text) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, false
+	}
+	return r, true
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+type SortOrder string
+
+const (
+	SortNone    SortOrder = ""
+	SortName    SortOrder = "name"
+	SortMtime   SortOrder = "mtime"
+	SortNatural SortOrder = "natural"
+)
+
+// globFilter selects found files by their slash-separated path relative to
+// the --input directory. Archive members look like "logs.zip!/cpu.csv".
+type globFilter struct {
+	include []string
+	exclude []string
+}
+
+func newGlobFilter(include []string, exclude []string) (*globFilter, error) {
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, ErrInvalidGlob.Details("pattern", pattern)
+		}
+	}
+	return &globFilter{include: include, exclude: exclude}, nil
+}
+
+func (g *globFilter) match(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	included := len(g.include) <= 0
+	for _, pattern := range g.include {
+		if ok, _ := doublestar.Match(pattern, rel); ok {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, pattern := range g.exclude {
+		if ok, _ := doublestar.Match(pattern, rel); ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *globFilter) filter(root string, inputs []Input) []Input {
+	matched := []Input{}
+	for _, input := range inputs {
+		rel, err := filepath.Rel(root, input.Pathname)
+		if err != nil || g.match(rel) {
+			matched = append(matched, input)
+		}
+	}
+	return matched
+}
+
+// sortInputs orders the inputs found for one --input value. SortNone keeps
+// the order they were found in.
+func sortInputs(inputs []Input, order SortOrder) {
+	switch order {
+	case SortName:
+		sort.SliceStable(inputs, func(i, j int) bool {
+			return inputs[i].Pathname < inputs[j].Pathname
+		})
+	case SortNatural:
+		sort.SliceStable(inputs, func(i, j int) bool {
+			return naturalLess(inputs[i].Pathname, inputs[j].Pathname)
+		})
+	case SortMtime:
+		mtimes := map[string]time.Time{}
+		for _, input := range inputs {
+			mtimes[input.Pathname] = modTime(input)
+		}
+		sort.SliceStable(inputs, func(i, j int) bool {
+			mi, mj := mtimes[inputs[i].Pathname], mtimes[inputs[j].Pathname]
+			if mi.Equal(mj) {
+				return inputs[i].Pathname < inputs[j].Pathname
+			}
+			return mi.Before(mj)
+		})
+	}
+}
+
+// modTime returns the modification time of the file, or of the archive for
+// an archive member.
+func modTime(input Input) time.Time {
+	pathname := input.Pathname
+	if len(input.Archive) > 0 {
+		pathname = input.Archive
+	}
+	stat, err := os.Stat(pathname)
+	if err != nil {
+		return time.Time{}
+	}
+	return stat.ModTime()
+}
+
+// naturalLess compares runs of digits by their numeric value, so that
+// "day2.csv" comes before "day10.csv". Other text is compared without case.
+func naturalLess(a string, b string) bool {
+	ia, ib := 0, 0
+	for ia < len(a) && ib < len(b) {
+		ca, cb := a[ia], b[ib]
+		if isDigit(ca) && isDigit(cb) {
+			ea, eb := digitsEnd(a, ia), digitsEnd(b, ib)
+			da := strings.TrimLeft(a[ia:ea], "0")
+			db := strings.TrimLeft(b[ib:eb], "0")
+			if len(da) != len(db) {
+				return len(da) < len(db)
+			}
+			if da != db {
+				return da < db
+			}
+			ia, ib = ea, eb
+			continue
+		}
+		la, lb := toLower(ca), toLower(cb)
+		if la != lb {
+			return la < lb
+		}
+		ia++
+		ib++
+	}
+	if len(a)-ia != len(b)-ib {
+		return len(a)-ia < len(b)-ib
+	}
+	return a < b
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func digitsEnd(s string, i int) int {
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+func toLower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestNaturalLess(t *testing.T) {
+	testcases := []struct {
+		a      string
+		b      string
+		expect bool
+	}{
+		{"day2.csv", "day10.csv", true},
+		{"day10.csv", "day2.csv", false},
+		// leading zeros
+		{"day002.csv", "day10.csv", true},
+		{"day010.csv", "day9.csv", false},
+		{"day01.csv", "day1.csv", true},
+		{"day1.csv", "day01.csv", false},
+		// mixed case
+		{"Day2.csv", "day10.csv", true},
+		{"a.csv", "B.csv", true},
+		{"b.csv", "A.csv", false},
+		{"A.csv", "a.csv", true},
+		{"a.csv", "A.csv", false},
+		// equal numeric runs
+		{"log1a.csv", "log1b.csv", true},
+		{"v1.2.csv", "v1.10.csv", true},
+		{"log1.csv", "log1.csv", false},
+		{"day1", "day1.csv", true},
+		// archive members
+		{"logs.zip!/day9.csv", "logs.zip!/day10.csv", true},
+		{"logs2.zip!/a.csv", "logs10.zip!/a.csv", true},
+		{"logs.zip!/z.csv", "logs.zip2", true},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expect, naturalLess(tc.a, tc.b))
+		})
+	}
+}
+
+func TestSortInputs(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	mtimes := map[string]time.Duration{"a.csv": 3 * time.Hour, "b.csv": time.Hour, "c10.csv": 0, "c9.csv": 2 * time.Hour, "logs.zip": time.Hour}
+	for name, d := range mtimes {
+		pathname := filepath.Join(dir, name)
+		assert.Nil(t, os.WriteFile(pathname, []byte("a\n"), 0644))
+		assert.Nil(t, os.Chtimes(pathname, now.Add(d), now.Add(d)))
+	}
+	member := Input{Pathname: filepath.Join(dir, "logs.zip!/x.csv"), Archive: filepath.Join(dir, "logs.zip"), Member: "x.csv"}
+	testcases := []struct {
+		names  []string
+		order  SortOrder
+		expect []string
+	}{
+		{[]string{"c10.csv", "a.csv", "c9.csv"}, SortNone, []string{"c10.csv", "a.csv", "c9.csv"}},
+		{[]string{"c10.csv", "a.csv", "c9.csv"}, SortName, []string{"a.csv", "c10.csv", "c9.csv"}},
+		{[]string{"c10.csv", "a.csv", "c9.csv"}, SortNatural, []string{"a.csv", "c9.csv", "c10.csv"}},
+		{[]string{"a.csv", "logs.zip!/x.csv", "b.csv", "c9.csv", "c10.csv"}, SortMtime, []string{"c10.csv", "b.csv", "logs.zip!/x.csv", "c9.csv", "a.csv"}},
+		// a file that is gone sorts first
+		{[]string{"a.csv", "gone.csv"}, SortMtime, []string{"gone.csv", "a.csv"}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			inputs := []Input{}
+			for _, name := range tc.names {
+				if name == "logs.zip!/x.csv" {
+					inputs = append(inputs, member)
+				} else {
+					inputs = append(inputs, Input{Pathname: filepath.Join(dir, name)})
+				}
+			}
+			sortInputs(inputs, tc.order)
+			actual := []string{}
+			for _, input := range inputs {
+				rel, err := filepath.Rel(dir, input.Pathname)
+				assert.Nil(t, err)
+				actual = append(actual, filepath.ToSlash(rel))
+			}
+			assert.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestGlobFilter(t *testing.T) {
+	testcases := []struct {
+		include   []string
+		exclude   []string
+		rel       string
+		expect    bool
+		expectErr error
+	}{
+		{nil, nil, "a.csv", true, nil},
+		{[]string{"*.csv"}, nil, "a.csv", true, nil},
+		{[]string{"*.csv"}, nil, "sub/a.csv", false, nil},
+		{[]string{"**/*.csv"}, nil, "sub/a.csv", true, nil},
+		{[]string{"*.tsv", "sub/*"}, nil, "sub/a.csv", true, nil},
+		{nil, []string{"sub/**"}, "sub/a.csv", false, nil},
+		{[]string{"**/*.csv"}, []string{"**/skip*"}, "sub/skip1.csv", false, nil},
+		{[]string{"**/*.csv"}, []string{"**/skip*"}, "sub/keep1.csv", true, nil},
+		// archive members
+		{[]string{"logs.zip!/*.csv"}, nil, "logs.zip!/cpu.csv", true, nil},
+		{[]string{"logs.zip!/*.csv"}, nil, "logs.zip!/sub/cpu.csv", false, nil},
+		{nil, []string{"*.zip!/**"}, "logs.zip!/sub/cpu.csv", false, nil},
+		{[]string{"[a"}, nil, "", false, ErrInvalidGlob},
+		{nil, []string{"{a"}, "", false, ErrInvalidGlob},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			glob, err := newGlobFilter(tc.include, tc.exclude)
+			if tc.expectErr != nil {
+				assert.True(t, errors.Is(err, tc.expectErr))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expect, glob.match(tc.rel))
+		})
+	}
+}
+
+func writeTestZip(t *testing.T, pathname string, members ...string) {
+	f, err := os.Create(pathname)
+	assert.Nil(t, err)
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, name := range members {
+		w, err := zw.Create(name)
+		assert.Nil(t, err)
+		_, err = w.Write([]byte("a,b\n1,2\n"))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, zw.Close())
+}
+
+func TestInputPathsArchive(t *testing.T) {
+	dir := t.TempDir()
+	logs := filepath.Join(dir, "logs.zip")
+	writeTestZip(t, logs, "cpu.csv", "mem.csv", "sub/disk.csv")
+	testcases := []struct {
+		input     string
+		include   []string
+		exclude   []string
+		extension string
+		expect    []string
+		expectErr error
+	}{
+		{logs, nil, nil, "csv", []string{"cpu.csv", "mem.csv", "sub/disk.csv"}, nil},
+		{logs, []string{"logs.zip!/*.csv"}, nil, "csv", []string{"cpu.csv", "mem.csv"}, nil},
+		{dir, nil, []string{"**/mem.csv"}, "csv", []string{"cpu.csv", "sub/disk.csv"}, nil},
+		// an archive with every member filtered out is like an empty directory
+		{logs, nil, []string{"logs.zip!/**"}, "csv", nil, ErrNotFoundInputFile},
+		{logs, []string{"*.tsv"}, nil, "csv", nil, ErrNotFoundInputFile},
+		{logs, nil, nil, "tsv", nil, ErrNotFoundInputFile},
+		{dir, nil, []string{"**/*.csv"}, "csv", nil, ErrNotFoundInputFile},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			p := newTestParam()
+			p.extension = tc.extension
+			c := &config{param: p, log: zap.NewNop()}
+			glob, err := newGlobFilter(tc.include, tc.exclude)
+			assert.Nil(t, err)
+			actual, err := c.inputPaths(tc.input, glob)
+			if tc.expectErr != nil {
+				assert.True(t, errors.Is(err, tc.expectErr))
+				return
+			}
+			assert.Nil(t, err)
+			members := []string{}
+			for _, input := range actual {
+				assert.Equal(t, logs, input.Archive)
+				members = append(members, input.Member)
+			}
+			assert.Equal(t, tc.expect, members)
+		})
+	}
+}
@@ -16,7 +16,34 @@ package config
 import "github.com/kenita8/errors"
 
 var (
-	ErrNotFoundInputFile = errors.New("input file not found")
-	ErrWalkInputDir      = errors.New("unable to find input file")
-	ErrConvertAbsPath    = errors.New("unable to convert file path to absolute path")
+	ErrNotFoundInputFile         = errors.New("input file not found")
+	ErrWalkInputDir              = errors.New("unable to find input file")
+	ErrConvertAbsPath            = errors.New("unable to convert file path to absolute path")
+	ErrSheetNameTemplate         = errors.New("unknown field in sheet name template")
+	ErrInvalidMode               = errors.New("mode must be replace, append, or skip")
+	ErrInvalidJsonArrays         = errors.New("json-arrays must be join, explode, or json")
+	ErrInvalidUnmatched          = errors.New("unmatched must be drop or sheet")
+	ErrWidthsWithLogPattern      = errors.New("widths and log-pattern cannot be used together")
+	ErrRequireFormat             = errors.New("format parameter is required to read from stdin")
+	ErrStdinTwice                = errors.New("stdin can be given as input only once")
+	ErrInvalidGlob               = errors.New("glob pattern is invalid")
+	ErrInvalidSort               = errors.New("sort must be name, mtime, or natural")
+	ErrInvalidJobs               = errors.New("jobs must be 1 or more")
+	ErrInvalidDelimiter          = errors.New("delimiter must be a single character, tab, or auto")
+	ErrInvalidComment            = errors.New("comment must be a single character other than the delimiter")
+	ErrInvalidSkipRows           = errors.New("skip-rows must be 0 or more")
+	ErrUnknownLocale             = errors.New("locale is not known")
+	ErrInvalidDecimalSep         = errors.New("decimal-sep must be a single character other than a digit, sign, or %")
+	ErrInvalidThousandsSep       = errors.New("thousands-sep must be characters other than digits, signs, or %, or space")
+	ErrSameSeparators            = errors.New("decimal and thousands separators must differ")
+	ErrInvalidOnError            = errors.New("on-error must be fail, skip, or report")
+	ErrErrorFileWithoutReport    = errors.New("error-file requires on-error report")
+	ErrInvalidSplit              = errors.New("split must be sheets or workbooks")
+	ErrColumnsWithoutHeader      = errors.New("columns can only be picked by index and not renamed without a header")
+	ErrWhereWithoutHeader        = errors.New("where can only use columns by index without a header")
+	ErrNumberFormatWithoutHeader = errors.New("number-format can only pick columns by index without a header")
+	ErrLoadManifest              = errors.New("unable to load manifest")
+	ErrInvalidManifest           = errors.New("manifest is invalid")
+	ErrInvalidPreset             = errors.New("preset must be perfmon")
+	ErrStripHostWithoutPreset    = errors.New("strip-host requires preset perfmon")
 )
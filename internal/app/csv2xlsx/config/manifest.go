@@ -0,0 +1,246 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/kenita8/xlcmd/internal/pkg/excel"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+// Manifest lists the inputs of a workbook and where each one goes, in place
+// of --input. Inputs sent to the same sheet become blocks of it.
+type Manifest struct {
+	Inputs []ManifestEntry `yaml:"Inputs"`
+}
+
+// ManifestEntry is a file, or a glob of files, and the sheet and cell it
+// starts at. Input is relative to the manifest, and names a file that exists
+// as it is, even with glob characters such as "[" in it. Sheet may use the
+// fields of --sheet-name. Fields left out take the value of the flag.
+type ManifestEntry struct {
+	Input           string `yaml:"Input"`
+	Sheet           string `yaml:"Sheet"`
+	StartCell       string `yaml:"StartCell"`
+	Encoding        string `yaml:"Encoding"`
+	Delimiter       string `yaml:"Delimiter"`
+	Comment         string `yaml:"Comment"`
+	LazyQuotes      *bool  `yaml:"LazyQuotes"`
+	TrimSpace       *bool  `yaml:"TrimSpace"`
+	FieldsPerRecord *int   `yaml:"FieldsPerRecord"`
+	SkipRows        *int   `yaml:"SkipRows"`
+	Header          *bool  `yaml:"Header"`
+	// NumberFormats are written like --number-format, e.g. "Rate=0.00%".
+	NumberFormats []string `yaml:"NumberFormats"`
+}
+
+// LoadManifest reads a manifest from a YAML file.
+func LoadManifest(pathname string) (*Manifest, error) {
+	data, err := os.ReadFile(pathname)
+	if err != nil {
+		return nil, ErrLoadManifest.Details("path", pathname).Wrap(err)
+	}
+	manifest := &Manifest{}
+	err = yaml.UnmarshalStrict(data, manifest)
+	if err != nil {
+		return nil, ErrLoadManifest.Details("path", pathname).Wrap(err)
+	}
+	if len(manifest.Inputs) <= 0 {
+		return nil, ErrInvalidManifest.Details("path", pathname, "reason", "no inputs")
+	}
+	dir := filepath.Dir(pathname)
+	for i, entry := range manifest.Inputs {
+		if len(entry.Input) <= 0 {
+			return nil, ErrInvalidManifest.Details("path", pathname, "entry", i+1, "reason", "input is required")
+		}
+		if !isFile(entryPath(dir, entry.Input)) && !doublestar.ValidatePattern(filepath.ToSlash(entry.Input)) {
+			return nil, ErrInvalidManifest.Details("path", pathname, "entry", i+1, "reason", "input is not a valid glob")
+		}
+	}
+	return manifest, nil
+}
+
+func entryPath(dir string, input string) string {
+	if filepath.IsAbs(input) {
+		return input
+	}
+	return filepath.Join(dir, input)
+}
+
+func isFile(pathname string) bool {
+	info, err := os.Stat(pathname)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// manifestInputs finds the inputs of the manifest in its order, stacking an
+// input on a sheet taken before unless it has its own start cell.
+func (c *config) manifestInputs() ([]Input, error) {
+	pathname := c.param.Manifest()
+	manifest, err := LoadManifest(pathname)
+	if err != nil {
+		return nil, err
+	}
+	base, err := c.InputOption()
+	if err != nil {
+		return nil, err
+	}
+	flagFormats, err := parseNumberFormats(c.param.NumberFormats())
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(pathname)
+	inputs := []Input{}
+	// entries are the indexes into the manifest of the entries of inputs.
+	entries := []int{}
+	for i, entry := range manifest.Inputs {
+		template := entry.Sheet
+		if len(template) <= 0 {
+			template = c.param.SheetName()
+		}
+		err = checkSheetNameTemplate(template)
+		if err != nil {
+			return nil, err
+		}
+		opt, err := c.entryOption(entry, base)
+		if err != nil {
+			return nil, ErrInvalidManifest.Details("path", pathname, "entry", i+1).Wrap(err)
+		}
+		formats, err := parseNumberFormats(entry.NumberFormats)
+		if err != nil {
+			return nil, ErrInvalidManifest.Details("path", pathname, "entry", i+1).Wrap(err)
+		}
+		header := c.header()
+		if entry.Header != nil {
+			header = *entry.Header
+		}
+		// The formats of the flags apply to the entry as well.
+		err = checkNumberFormats(append(slices.Clip(flagFormats), formats...), header)
+		if err != nil {
+			return nil, ErrInvalidManifest.Details("path", pathname, "entry", i+1).Wrap(err)
+		}
+		pattern := entryPath(dir, entry.Input)
+		found := []string{pattern}
+		if !isFile(pattern) {
+			found, err = doublestar.FilepathGlob(pattern, doublestar.WithFilesOnly())
+			if err != nil {
+				return nil, ErrWalkInputDir.Wrap(err)
+			}
+		}
+		if len(found) <= 0 {
+			return nil, ErrNotFoundInputFile.Details("input", entry.Input)
+		}
+		slices.Sort(found)
+		for _, path := range found {
+			path, err = filepath.Abs(path)
+			if err != nil {
+				return nil, ErrConvertAbsPath.Wrap(err)
+			}
+			inputs = append(inputs, Input{
+				Pathname:      path,
+				Sheet:         excel.SanitizeSheetName(sheetName(template, path, len(inputs)+1)),
+				StartCell:     entry.StartCell,
+				Header:        entry.Header,
+				Option:        opt,
+				NumberFormats: formats,
+			})
+			entries = append(entries, i)
+		}
+	}
+	renameReserved(inputs, c.reservedSheets())
+	// sheets are the entries that wrote to a sheet so far, by sheet in lower
+	// case.
+	sheets := map[string]map[int]bool{}
+	for i := range inputs {
+		input := &inputs[i]
+		key := strings.ToLower(input.Sheet)
+		entry := entries[i]
+		input.Stacked = sheets[key][entry] || (len(sheets[key]) > 0 && len(input.StartCell) <= 0)
+		if sheets[key] == nil {
+			sheets[key] = map[int]bool{}
+		}
+		sheets[key][entry] = true
+		c.log.Info("input", zap.String("path", input.Pathname), zap.String("sheet", input.Sheet), zap.String("cell", input.StartCell))
+	}
+	return inputs, nil
+}
+
+// renameReserved moves the inputs on a reserved sheet to one free name.
+func renameReserved(inputs []Input, reserved []string) {
+	isReserved := func(sheet string) bool {
+		return slices.ContainsFunc(reserved, func(name string) bool { return strings.EqualFold(name, sheet) })
+	}
+	taken := slices.Clone(reserved)
+	for _, input := range inputs {
+		if !isReserved(input.Sheet) {
+			taken = append(taken, input.Sheet)
+		}
+	}
+	namer := excel.NewSheetNamer(taken...)
+	renamed := map[string]string{}
+	for i := range inputs {
+		key := strings.ToLower(inputs[i].Sheet)
+		if !isReserved(key) {
+			continue
+		}
+		if _, ok := renamed[key]; !ok {
+			renamed[key] = namer.Name(inputs[i].Sheet)
+		}
+		inputs[i].Sheet = renamed[key]
+	}
+}
+
+// entryOption returns the reader options of an entry, which are those of the
+// flags with the fields of the entry set over them.
+func (c *config) entryOption(entry ManifestEntry, base *InputOption) (*InputOption, error) {
+	opt := *base
+	if len(entry.Encoding) > 0 {
+		opt.Encoding = entry.Encoding
+	}
+	delimiter := c.param.Delimiter()
+	if len(entry.Delimiter) > 0 {
+		delimiter = entry.Delimiter
+	}
+	comment := c.param.Comment()
+	if len(entry.Comment) > 0 {
+		comment = entry.Comment
+	}
+	lazyQuotes := c.param.LazyQuotes()
+	if entry.LazyQuotes != nil {
+		lazyQuotes = *entry.LazyQuotes
+	}
+	trimSpace := c.param.TrimSpace()
+	if entry.TrimSpace != nil {
+		trimSpace = *entry.TrimSpace
+	}
+	fieldsPerRecord := c.param.FieldsPerRecord()
+	if entry.FieldsPerRecord != nil {
+		fieldsPerRecord = *entry.FieldsPerRecord
+	}
+	skipRows := c.param.SkipRows()
+	if entry.SkipRows != nil {
+		skipRows = *entry.SkipRows
+	}
+	csvOpt, err := newCsvOption(delimiter, comment, lazyQuotes, trimSpace, fieldsPerRecord, skipRows)
+	if err != nil {
+		return nil, err
+	}
+	opt.Csv = csvOpt
+	return &opt, nil
+}
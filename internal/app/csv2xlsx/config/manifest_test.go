@@ -0,0 +1,350 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/kenita8/xlcmd/internal/app/csv2xlsx/param"
+	"github.com/kenita8/xlcmd/internal/pkg/excel"
+	"github.com/kenita8/xlcmd/internal/pkg/file/json"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// testParam is the flags used by the tests, left at their defaults unless a
+// test sets them. Other getters are not used and panic.
+type testParam struct {
+	param.Param
+	manifest      string
+	sheetName     string
+	header        bool
+	delimiter     string
+	numberFormats []string
+	extension     string
+	depth         int
+	unmatched     string
+	preset        string
+	onError       string
+	errorFile     string
+	dateLayouts   string
+}
+
+func newTestParam() *testParam {
+	return &testParam{sheetName: "{stem}", header: true, extension: "csv,tsv", depth: 10, unmatched: "drop", onError: "fail"}
+}
+
+func (p *testParam) Manifest() string        { return p.manifest }
+func (p *testParam) SheetName() string       { return p.sheetName }
+func (p *testParam) Header() bool            { return p.header }
+func (p *testParam) NumberFormats() []string { return p.numberFormats }
+func (p *testParam) Encoding() string        { return "utf-8" }
+func (p *testParam) JsonArrays() string      { return "join" }
+func (p *testParam) LogPattern() string      { return "" }
+func (p *testParam) Widths() string          { return "" }
+func (p *testParam) Unmatched() string       { return p.unmatched }
+func (p *testParam) Preset() string          { return p.preset }
+func (p *testParam) OnError() string         { return p.onError }
+func (p *testParam) ErrorFile() string       { return p.errorFile }
+func (p *testParam) StripHost() bool         { return false }
+func (p *testParam) Delimiter() string       { return p.delimiter }
+func (p *testParam) Comment() string         { return "" }
+func (p *testParam) LazyQuotes() bool        { return false }
+func (p *testParam) TrimSpace() bool         { return false }
+func (p *testParam) FieldsPerRecord() int    { return 0 }
+func (p *testParam) SkipRows() int           { return 0 }
+func (p *testParam) Extension() string       { return p.extension }
+func (p *testParam) Depth() int              { return p.depth }
+func (p *testParam) DecimalPlaces() int      { return 2 }
+func (p *testParam) Round() bool             { return false }
+func (p *testParam) InferTypes() bool        { return true }
+func (p *testParam) DateLayouts() string     { return p.dateLayouts }
+func (p *testParam) Locale() string          { return "" }
+func (p *testParam) DecimalSep() string      { return "" }
+func (p *testParam) ThousandsSep() string    { return "" }
+func (p *testParam) Columns() string         { return "" }
+func (p *testParam) Where() string           { return "" }
+
+// writeManifest writes the manifest and the files it may refer to in a new
+// directory, and returns the path of the manifest.
+func writeManifest(t *testing.T, manifest string) string {
+	dir := t.TempDir()
+	for _, name := range []string{"data/b.csv", "data/a.csv", "data/c.tsv", "top.csv"} {
+		pathname := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(pathname), 0755))
+		assert.Nil(t, os.WriteFile(pathname, []byte("a,b\n1,2\n"), 0644))
+	}
+	pathname := filepath.Join(dir, "manifest.yaml")
+	assert.Nil(t, os.WriteFile(pathname, []byte(manifest), 0644))
+	return pathname
+}
+
+func TestLoadManifest(t *testing.T) {
+	yes := true
+	rows := 2
+	testcases := []struct {
+		manifest  string
+		expect    *Manifest
+		expectErr error
+	}{
+		{
+			"Inputs:\n  - Input: data/*.csv\n  - Input: top.csv\n    Sheet: Top\n    StartCell: B2\n",
+			&Manifest{Inputs: []ManifestEntry{{Input: "data/*.csv"}, {Input: "top.csv", Sheet: "Top", StartCell: "B2"}}},
+			nil,
+		},
+		{
+			"Inputs:\n  - Input: top.csv\n    Header: true\n    SkipRows: 2\n    NumberFormats: [\"Rate=0.00%\"]\n",
+			&Manifest{Inputs: []ManifestEntry{{Input: "top.csv", Header: &yes, SkipRows: &rows, NumberFormats: []string{"Rate=0.00%"}}}},
+			nil,
+		},
+		{"Inputs:\n  - Input: top.csv\n    Sheets: Top\n", nil, ErrLoadManifest},
+		{"Input: top.csv\n", nil, ErrLoadManifest},
+		{"Inputs:\n  - Input: top.csv\n    SkipRows: many\n", nil, ErrLoadManifest},
+		{"Inputs: []\n", nil, ErrInvalidManifest},
+		{"Inputs:\n  - Sheet: Top\n", nil, ErrInvalidManifest},
+		{"Inputs:\n  - Input: data/[a.csv\n", nil, ErrInvalidManifest},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := LoadManifest(writeManifest(t, tc.manifest))
+			if tc.expectErr != nil {
+				assert.True(t, errors.Is(err, tc.expectErr))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestLoadManifestNotFound(t *testing.T) {
+	_, err := LoadManifest(filepath.Join(t.TempDir(), "manifest.yaml"))
+	assert.True(t, errors.Is(err, ErrLoadManifest))
+}
+
+func TestManifestInputs(t *testing.T) {
+	no := false
+	base := &InputOption{Encoding: "utf-8", JsonArrays: json.ArrayJoin, Csv: &CsvOption{}}
+	type input struct {
+		name      string
+		sheet     string
+		startCell string
+		header    *bool
+		stacked   bool
+	}
+	testcases := []struct {
+		manifest  string
+		delimiter string
+		expect    []input
+		expectOpt *InputOption
+		expectErr error
+	}{
+		// globs are relative to the manifest and their files are in name order
+		{
+			"Inputs:\n  - Input: data/*.csv\n  - Input: top.csv\n    StartCell: C3\n",
+			"",
+			[]input{{"data/a.csv", "a", "", nil, false}, {"data/b.csv", "b", "", nil, false}, {"top.csv", "top", "C3", nil, false}},
+			base,
+			nil,
+		},
+		// the inputs after the first on a sheet are stacked
+		{
+			"Inputs:\n  - Input: data/*\n    Sheet: all\n  - Input: top.csv\n    Sheet: all\n",
+			"",
+			[]input{{"data/a.csv", "all", "", nil, false}, {"data/b.csv", "all", "", nil, true}, {"data/c.tsv", "all", "", nil, true}, {"top.csv", "all", "", nil, true}},
+			base,
+			nil,
+		},
+		// unless their entry gives a start cell
+		{
+			"Inputs:\n  - Input: data/a.csv\n    Sheet: all\n  - Input: data/b.csv\n    Sheet: all\n  - Input: top.csv\n    Sheet: all\n    StartCell: E1\n",
+			"",
+			[]input{{"data/a.csv", "all", "", nil, false}, {"data/b.csv", "all", "", nil, true}, {"top.csv", "all", "E1", nil, false}},
+			base,
+			nil,
+		},
+		{
+			"Inputs:\n  - Input: data/*\n    Sheet: \"{ext}-{index}\"\n",
+			"",
+			[]input{{"data/a.csv", "csv-1", "", nil, false}, {"data/b.csv", "csv-2", "", nil, false}, {"data/c.tsv", "tsv-3", "", nil, false}},
+			base,
+			nil,
+		},
+		// the fields of an entry are set over the flags
+		{
+			"Inputs:\n  - Input: top.csv\n    Encoding: shift_jis\n    Delimiter: tab\n    Comment: \"#\"\n    LazyQuotes: true\n    TrimSpace: true\n    FieldsPerRecord: -1\n    SkipRows: 2\n    Header: false\n",
+			";",
+			[]input{{"top.csv", "top", "", &no, false}},
+			&InputOption{Encoding: "shift_jis", JsonArrays: json.ArrayJoin, Csv: &CsvOption{
+				Delimiter: '\t', Comment: '#', LazyQuotes: true, TrimSpace: true, FieldsPerRecord: -1, SkipRows: 2,
+			}},
+			nil,
+		},
+		{
+			"Inputs:\n  - Input: top.csv\n",
+			";",
+			[]input{{"top.csv", "top", "", nil, false}},
+			&InputOption{Encoding: "utf-8", JsonArrays: json.ArrayJoin, Csv: &CsvOption{Delimiter: ';'}},
+			nil,
+		},
+		{"Inputs:\n  - Input: data/*.json\n", "", nil, nil, ErrNotFoundInputFile},
+		{"Inputs:\n  - Input: top.csv\n    Sheet: \"{name}\"\n", "", nil, nil, ErrSheetNameTemplate},
+		{"Inputs:\n  - Input: top.csv\n    Delimiter: ab\n", "", nil, nil, ErrInvalidManifest},
+		{"Inputs:\n  - Input: top.csv\n    Comment: \";\"\n", ";", nil, nil, ErrInvalidManifest},
+		{"Inputs:\n  - Input: top.csv\n    SkipRows: -1\n", "", nil, nil, ErrInvalidManifest},
+		{"Inputs:\n  - Input: top.csv\n    NumberFormats: [\"Rate\"]\n", "", nil, nil, ErrInvalidManifest},
+		{"Inputs:\n  - Input: top.csv\n    Unknown: 1\n", "", nil, nil, ErrLoadManifest},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			p := newTestParam()
+			p.manifest = writeManifest(t, tc.manifest)
+			p.delimiter = tc.delimiter
+			c := &config{param: p, log: zap.NewNop()}
+			actual, err := c.manifestInputs()
+			if tc.expectErr != nil {
+				assert.True(t, errors.Is(err, tc.expectErr))
+				return
+			}
+			assert.Nil(t, err)
+			if !assert.Len(t, actual, len(tc.expect)) {
+				return
+			}
+			dir := filepath.Dir(p.manifest)
+			for j, expect := range tc.expect {
+				assert.Equal(t, filepath.Join(dir, expect.name), actual[j].Pathname)
+				assert.Equal(t, expect.sheet, actual[j].Sheet)
+				assert.Equal(t, expect.startCell, actual[j].StartCell)
+				assert.Equal(t, expect.header, actual[j].Header)
+				assert.Equal(t, expect.stacked, actual[j].Stacked)
+				assert.Equal(t, tc.expectOpt, actual[j].Option)
+				assert.Equal(t, []excel.NumberFormat{}, actual[j].NumberFormats)
+			}
+		})
+	}
+}
+
+func TestManifestInputsReservedSheets(t *testing.T) {
+	p := newTestParam()
+	p.preset = "perfmon"
+	p.onError = "report"
+	p.manifest = writeManifest(t, "Inputs:\n  - Input: top.csv\n    Sheet: Counters\n  - Input: data/a.csv\n    Sheet: _errors\n  - Input: data/b.csv\n    Sheet: unparsed\n")
+	c := &config{param: p, log: zap.NewNop()}
+	actual, err := c.manifestInputs()
+	assert.Nil(t, err)
+	sheets := []string{}
+	for _, input := range actual {
+		sheets = append(sheets, input.Sheet)
+	}
+	assert.Equal(t, []string{"Counters (2)", "_errors (2)", "unparsed"}, sheets)
+
+	// the new name is not one an input takes, and inputs on the same
+	// reserved sheet share it
+	p.manifest = writeManifest(t, "Inputs:\n  - Input: top.csv\n    Sheet: counters\n  - Input: data/a.csv\n    Sheet: counters (2)\n  - Input: data/b.csv\n    Sheet: Counters\n")
+	actual, err = c.manifestInputs()
+	assert.Nil(t, err)
+	sheets = []string{}
+	stacked := []bool{}
+	for _, input := range actual {
+		sheets = append(sheets, input.Sheet)
+		stacked = append(stacked, input.Stacked)
+	}
+	assert.Equal(t, []string{"counters (3)", "counters (2)", "counters (3)"}, sheets)
+	assert.Equal(t, []bool{false, false, true}, stacked)
+}
+
+func TestManifestInputsLiteralPath(t *testing.T) {
+	p := newTestParam()
+	p.manifest = writeManifest(t, "Inputs:\n  - Input: data/[x].csv\n  - Input: data/[y.csv\n")
+	dir := filepath.Dir(p.manifest)
+	for _, name := range []string{"data/[x].csv", "data/x.csv", "data/[y.csv"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte("a,b\n1,2\n"), 0644))
+	}
+	_, err := LoadManifest(p.manifest)
+	assert.Nil(t, err)
+	c := &config{param: p, log: zap.NewNop()}
+	actual, err := c.manifestInputs()
+	assert.Nil(t, err)
+	pathnames := []string{}
+	for _, input := range actual {
+		pathnames = append(pathnames, input.Pathname)
+	}
+	assert.Equal(t, []string{filepath.Join(dir, "data/[x].csv"), filepath.Join(dir, "data/[y.csv")}, pathnames)
+}
+
+func TestManifestInputsFlagNumberFormats(t *testing.T) {
+	p := newTestParam()
+	p.numberFormats = []string{"Rate"}
+	p.manifest = writeManifest(t, "Inputs:\n  - Input: top.csv\n")
+	c := &config{param: p, log: zap.NewNop()}
+	_, err := c.manifestInputs()
+	assert.True(t, errors.Is(err, excel.ErrInvalidNumberFormat))
+}
+
+func TestManifestInputsNumberFormatsHeader(t *testing.T) {
+	testcases := []struct {
+		header        bool
+		numberFormats []string
+		manifest      string
+		expectErr     error
+	}{
+		{true, nil, "Inputs:\n  - Input: top.csv\n    NumberFormats: [\"Rate=0.00%\"]\n", nil},
+		{true, nil, "Inputs:\n  - Input: top.csv\n    Header: false\n    NumberFormats: [\"2=0.00\"]\n", nil},
+		{true, nil, "Inputs:\n  - Input: top.csv\n    Header: false\n    NumberFormats: [\"Rate=0.00%\"]\n", ErrNumberFormatWithoutHeader},
+		{true, nil, "Inputs:\n  - Input: top.csv\n    Header: false\n    NumberFormats: [\"/Rate/=0.00%\"]\n", ErrNumberFormatWithoutHeader},
+		{false, nil, "Inputs:\n  - Input: top.csv\n    NumberFormats: [\"Rate=0.00%\"]\n", ErrNumberFormatWithoutHeader},
+		{false, nil, "Inputs:\n  - Input: top.csv\n    Header: true\n    NumberFormats: [\"Rate=0.00%\"]\n", nil},
+		{true, []string{"Rate=0.00%"}, "Inputs:\n  - Input: top.csv\n    Header: false\n", ErrNumberFormatWithoutHeader},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			p := newTestParam()
+			p.header = tc.header
+			p.numberFormats = tc.numberFormats
+			p.manifest = writeManifest(t, tc.manifest)
+			c := &config{param: p, log: zap.NewNop()}
+			_, err := c.manifestInputs()
+			if tc.expectErr != nil {
+				assert.True(t, errors.Is(err, ErrInvalidManifest))
+				assert.True(t, errors.Is(err, tc.expectErr))
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestManifestInputsNumberFormats(t *testing.T) {
+	p := newTestParam()
+	p.manifest = writeManifest(t, "Inputs:\n  - Input: data/*.csv\n    NumberFormats: [\"2=0.00\", \"Rate=0.00%\"]\n")
+	c := &config{param: p, log: zap.NewNop()}
+	actual, err := c.manifestInputs()
+	assert.Nil(t, err)
+	if !assert.Len(t, actual, 2) {
+		return
+	}
+	for _, input := range actual {
+		if assert.Len(t, input.NumberFormats, 2) {
+			assert.Equal(t, excel.ColumnSelector{Index: 2}, input.NumberFormats[0].Column)
+			assert.Equal(t, "0.00", input.NumberFormats[0].Code)
+			assert.Equal(t, "Rate", input.NumberFormats[1].Column.Name)
+			assert.Equal(t, "0.00%", input.NumberFormats[1].Code)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kenita8/xlcmd/internal/pkg/excel"
+)
+
+const (
+	// spaceThousands stands for the spaces used as thousands separators.
+	spaceThousands = "space"
+)
+
+// numberLocale returns the number conventions of the inputs, or nil to read
+// numbers as before. The separators given override those of the locale.
+func (c *config) numberLocale() (*excel.NumberLocale, error) {
+	name := c.param.Locale()
+	decimal := c.param.DecimalSep()
+	thousands := c.param.ThousandsSep()
+	if len(name) <= 0 && len(decimal) <= 0 && len(thousands) <= 0 {
+		return nil, nil
+	}
+	number := &excel.NumberLocale{Decimal: '.', Thousands: ","}
+	if len(name) > 0 {
+		loc, ok := excel.LookupLocale(name)
+		if !ok {
+			return nil, ErrUnknownLocale.Details("locale", name)
+		}
+		number = loc
+	}
+	if len(decimal) > 0 {
+		r, size := utf8.DecodeRuneInString(decimal)
+		if size != len(decimal) || !validSeparator(r) {
+			return nil, ErrInvalidDecimalSep.Details("decimal-sep", decimal)
+		}
+		number.Decimal = r
+		if len(name) <= 0 && r == ',' {
+			number.Thousands = "."
+		}
+	}
+	if strings.EqualFold(thousands, spaceThousands) {
+		number.Thousands = excel.SpaceSeparators
+	} else if len(thousands) > 0 {
+		for _, r := range thousands {
+			if !validSeparator(r) {
+				return nil, ErrInvalidThousandsSep.Details("thousands-sep", thousands)
+			}
+		}
+		number.Thousands = thousands
+	}
+	if strings.ContainsRune(number.Thousands, number.Decimal) {
+		return nil, ErrSameSeparators.Details("decimal", string(number.Decimal), "thousands", number.Thousands)
+	}
+	return number, nil
+}
+
+func validSeparator(r rune) bool {
+	return r != utf8.RuneError && !unicode.IsDigit(r) && !strings.ContainsRune("+-%", r)
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kenita8/xlcmd/internal/pkg/excel"
+)
+
+const (
+	// UnparsedSheet, CountersSheet and ErrorsSheet are the sheets written
+	// besides those of the inputs.
+	UnparsedSheet = "unparsed"
+	CountersSheet = "counters"
+	ErrorsSheet   = "_errors"
+)
+
+var (
+	sheetNameField = regexp.MustCompile(`\{([a-z]+)\}`)
+	sheetNameKeys  = []string{"base", "stem", "dir", "index", "ext"}
+)
+
+func checkSheetNameTemplate(template string) error {
+	for _, match := range sheetNameField.FindAllStringSubmatch(template, -1) {
+		if !slices.Contains(sheetNameKeys, match[1]) {
+			return ErrSheetNameTemplate.Details("template", template, "field", match[0])
+		}
+	}
+	return nil
+}
+
+// sheetName expands the template for the index-th (1-based) input. The
+// directory of a top-level archive member is the archive itself.
+func sheetName(template string, pathname string, index int) string {
+	base := filepath.Base(pathname)
+	ext := filepath.Ext(base)
+	fields := map[string]string{
+		"base":  base,
+		"stem":  strings.TrimSuffix(base, ext),
+		"dir":   strings.TrimSuffix(filepath.Base(filepath.Dir(pathname)), "!"),
+		"index": strconv.Itoa(index),
+		"ext":   strings.TrimPrefix(ext, "."),
+	}
+	return sheetNameField.ReplaceAllStringFunc(template, func(field string) string {
+		return fields[field[1:len(field)-1]]
+	})
+}
+
+// reservedSheets returns the names of the sheets the flags make written
+// besides those of the inputs, which inputs must not take.
+func (c *config) reservedSheets() []string {
+	reserved := []string{}
+	if strings.EqualFold(c.param.Unmatched(), "sheet") {
+		reserved = append(reserved, UnparsedSheet)
+	}
+	if strings.EqualFold(c.param.Preset(), "perfmon") {
+		reserved = append(reserved, CountersSheet)
+	}
+	if strings.EqualFold(c.param.OnError(), string(excel.OnErrorReport)) && len(c.param.ErrorFile()) <= 0 {
+		reserved = append(reserved, ErrorsSheet)
+	}
+	return reserved
+}
+
+// nameSheets names a valid, unique sheet for every input without one.
+func nameSheets(template string, inputs []Input, reserved []string) {
+	namer := excel.NewSheetNamer(reserved...)
+	for i := range inputs {
+		name := inputs[i].Sheet
+		if len(name) <= 0 {
+			name = sheetName(template, inputs[i].Pathname, i+1)
+		}
+		inputs[i].Sheet = namer.Name(name)
+	}
+}
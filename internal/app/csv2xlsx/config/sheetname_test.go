@@ -0,0 +1,51 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestNameSheetsReserved(t *testing.T) {
+	testcases := []struct {
+		unmatched string
+		preset    string
+		onError   string
+		errorFile string
+		expect    []string
+	}{
+		{"drop", "", "fail", "", []string{"counters", "unparsed", "_errors", "data"}},
+		{"sheet", "perfmon", "fail", "", []string{"counters (2)", "unparsed (2)", "_errors", "data"}},
+		{"drop", "", "report", "", []string{"counters", "unparsed", "_errors (2)", "data"}},
+		{"drop", "", "report", "errors.csv", []string{"counters", "unparsed", "_errors", "data"}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			p := newTestParam()
+			p.unmatched, p.preset, p.onError, p.errorFile = tc.unmatched, tc.preset, tc.onError, tc.errorFile
+			c := &config{param: p, log: zap.NewNop()}
+			inputs := []Input{{Pathname: "/in/counters.csv"}, {Pathname: "/in/unparsed.log"}, {Pathname: "/in/_errors.csv"}, {Pathname: "/in/data.csv"}}
+			nameSheets("{stem}", inputs, c.reservedSheets())
+			sheets := []string{}
+			for _, input := range inputs {
+				sheets = append(sheets, input.Sheet)
+			}
+			assert.Equal(t, tc.expect, sheets)
+		})
+	}
+}
@@ -15,12 +15,20 @@ package csv2xlsx
 
 import (
 	"context"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/kenita8/xlcmd/internal/app/csv2xlsx/config"
 	"github.com/kenita8/xlcmd/internal/pkg/excel"
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+	"github.com/kenita8/xlcmd/internal/pkg/file/archive"
 	"github.com/kenita8/xlcmd/internal/pkg/file/csv"
+	"github.com/kenita8/xlcmd/internal/pkg/file/fixed"
+	"github.com/kenita8/xlcmd/internal/pkg/file/json"
+	"github.com/kenita8/xlcmd/internal/pkg/file/logfile"
+	"github.com/kenita8/xlcmd/internal/pkg/file/perfmon"
 	"github.com/kenita8/xlcmd/internal/pkg/file/tsv"
 	"github.com/kenita8/xlcmd/internal/pkg/file/txt"
 
@@ -28,15 +36,23 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	unparsedSheet = config.UnparsedSheet
+	countersSheet = config.CountersSheet
+)
+
 type Csv2Xlsx struct {
 	log   *zap.Logger
 	excel Excel
 }
 
 type Excel interface {
-	Open(pathname string) error
+	OpenWithTemplate(pathname string, template string) error
 	NewSheet(name string) error
-	PasteTxtFile(txt txt.TxtFiler, sheet string, opt *excel.CellOption) error
+	PasteTxtFile(txt txt.TxtFiler, sheet string, opt *excel.CellOption, sheetOpt *excel.SheetOption) error
+	PasteTxtFiles(jobs []*excel.PasteJob, workers int, done func(job *excel.PasteJob, err error) error) error
+	Rejected() []excel.RejectedRow
+	CellNameToCoordinates(cell string) (int, int, error)
 	Save() error
 	Close()
 }
@@ -57,41 +73,224 @@ func NewCsv2Xlsx(lc fx.Lifecycle, config config.Config, excel Excel, log *zap.Lo
 	return c2x
 }
 
-func newInputFile(pathname string, encoding string) (txt.TxtFiler, error) {
+func newInputFile(pathname string, opt *config.InputOption) (txt.TxtFiler, error) {
 	ext := strings.ToLower(filepath.Ext(pathname))
+	encoding := opt.Encoding
 	var input txt.TxtFiler
 	if ext == ".csv" {
-		input = csv.NewCsvFile(pathname, encoding)
+		input = withPerfmon(withCsvOption(csv.NewCsvFile(pathname, encoding), opt.Csv), opt)
 	} else if ext == ".tsv" {
-		input = tsv.NewTsvFile(pathname, encoding)
-	} else if ext == ".txt" {
+		input = withPerfmon(withCsvOption(tsv.NewTsvFile(pathname, encoding), opt.Csv), opt)
+	} else if (ext == ".txt" || ext == ".dat") && opt.Widths != nil {
+		input = fixed.NewFixedFile(pathname, encoding, opt.Widths)
+	} else if (ext == ".txt" || ext == ".log") && opt.LogPattern != nil {
+		input = logfile.NewLogFile(pathname, encoding, opt.LogPattern)
+	} else if ext == ".txt" || ext == ".log" || ext == ".dat" {
 		input = txt.NewTxtFile(pathname, encoding)
+	} else if ext == ".json" {
+		jsonFile := json.NewJsonFile(pathname, encoding)
+		jsonFile.Arrays = opt.JsonArrays
+		input = jsonFile
+	} else if ext == ".ndjson" {
+		jsonFile := json.NewNdjsonFile(pathname, encoding)
+		jsonFile.Arrays = opt.JsonArrays
+		input = jsonFile
 	} else {
 		return nil, ErrInputFileExtension.Details("ext", ext)
 	}
 	return input, nil
 }
 
+func withCsvOption(input *csv.CsvFile, opt *config.CsvOption) *csv.CsvFile {
+	if opt == nil {
+		return input
+	}
+	if opt.Delimiter != 0 {
+		input.Comma = opt.Delimiter
+	}
+	input.Sniff = opt.Sniff
+	input.Comment = opt.Comment
+	input.LazyQuotes = opt.LazyQuotes
+	input.TrimSpace = opt.TrimSpace
+	input.FieldsPerRecord = opt.FieldsPerRecord
+	input.SkipRows = opt.SkipRows
+	return input
+}
+
+// withPerfmon reads a csv or tsv input as a Performance Monitor log with the
+// perfmon preset.
+func withPerfmon(input *csv.CsvFile, opt *config.InputOption) txt.TxtFiler {
+	if !opt.Perfmon {
+		return input
+	}
+	return perfmon.NewPerfmonFile(input, opt.StripHost)
+}
+
+// targetOption returns the options of a target, which differ from those of
+// the flags for a manifest entry or a sheet shared with other targets.
+func targetOption(target config.Input, opt *excel.CellOption, sheetOpt *excel.SheetOption, shared bool) (*excel.CellOption, *excel.SheetOption) {
+	if (target.Header != nil && *target.Header != opt.Header) || len(target.NumberFormats) > 0 {
+		entryOpt := *opt
+		if target.Header != nil {
+			entryOpt.Header = *target.Header
+		}
+		entryOpt.NumberFormats = append(slices.Clip(opt.NumberFormats), target.NumberFormats...)
+		opt = &entryOpt
+	}
+	if len(target.StartCell) > 0 || shared {
+		blockOpt := *sheetOpt
+		if len(target.StartCell) > 0 {
+			blockOpt.StartCell = target.StartCell
+		}
+		blockOpt.InPlace = shared
+		if target.Stacked {
+			blockOpt.Mode = excel.PasteModeAppend
+			blockOpt.SkipHeader = opt.Header
+		}
+		sheetOpt = &blockOpt
+	}
+	return opt, sheetOpt
+}
+
+// newTargetFile returns a reader of the target, copying stdin and archive
+// members to a temporary file that remove deletes.
+func newTargetFile(target config.Input, opt *config.InputOption) (txt.TxtFiler, func(), error) {
+	var pathname string
+	var err error
+	if len(target.Format) > 0 {
+		pathname, err = file.Spool(os.Stdin, "", "stdin."+target.Format)
+	} else if len(target.Archive) > 0 {
+		pathname, err = archive.Extract(target.Archive, target.Member, "")
+	} else {
+		input, err := newInputFile(target.Pathname, opt)
+		return input, func() {}, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	remove := func() {
+		os.Remove(pathname)
+	}
+	input, err := newInputFile(pathname, opt)
+	if err != nil {
+		remove()
+		return nil, nil, err
+	}
+	if namer, ok := input.(txt.Namer); ok {
+		namer.SetName(target.Pathname)
+	}
+	return input, remove, nil
+}
+
 func (c2x *Csv2Xlsx) convertExcel(config config.Config) error {
-	targets, encoding, err := config.InputFiles()
+	targets, err := config.InputFiles()
+	if err != nil {
+		return err
+	}
+	inputOpt, err := config.InputOption()
 	if err != nil {
 		return err
 	}
 	output := config.XlsxFilename()
-	opt := config.CellOption()
+	opt, err := config.CellOption()
+	if err != nil {
+		return err
+	}
+	sheetOpt, err := config.SheetOption()
+	if err != nil {
+		return err
+	}
+	workers, err := config.Jobs()
+	if err != nil {
+		return err
+	}
+	errorFile, err := config.ErrorFile()
+	if err != nil {
+		return err
+	}
 
-	err = c2x.excel.Open(output)
+	err = c2x.excel.OpenWithTemplate(output, config.Template())
 	if err != nil {
 		return err
 	}
 	defer c2x.excel.Close()
 
+	jobs := []*excel.PasteJob{}
+	removes := map[*excel.PasteJob]func(){}
+	defer func() {
+		for _, remove := range removes {
+			remove()
+		}
+	}()
+	// A sheet written by more than one input has each of them as a block.
+	blocks := map[string]int{}
+	for _, target := range targets {
+		blocks[strings.ToLower(target.Sheet)] += 1
+		if len(target.StartCell) > 0 {
+			_, _, err = c2x.excel.CellNameToCoordinates(target.StartCell)
+			if err != nil {
+				return ErrInvalidStartCell.Details("cell", target.StartCell).Wrap(err)
+			}
+		}
+	}
+	if len(sheetOpt.StartCell) > 0 {
+		_, _, err = c2x.excel.CellNameToCoordinates(sheetOpt.StartCell)
+		if err != nil {
+			return ErrInvalidStartCell.Details("cell", sheetOpt.StartCell).Wrap(err)
+		}
+	}
 	for _, target := range targets {
-		input, err := newInputFile(target, encoding)
+		targetOpt := inputOpt
+		if target.Option != nil {
+			targetOpt = target.Option
+		}
+		input, remove, err := newTargetFile(target, targetOpt)
 		if err != nil {
 			return err
 		}
-		err = c2x.excel.PasteTxtFile(input, input.Basename(), opt)
+		cellOpt, targetSheetOpt := targetOption(target, opt, sheetOpt, blocks[strings.ToLower(target.Sheet)] > 1)
+		job := &excel.PasteJob{Txt: input, Sheet: target.Sheet, CellOption: cellOpt, SheetOption: targetSheetOpt}
+		jobs = append(jobs, job)
+		removes[job] = remove
+	}
+
+	// Every input with unparsed lines adds a block to the unparsed sheet.
+	unparsed := &excel.SheetOption{Mode: sheetOpt.Mode, InPlace: true, AutoFit: sheetOpt.AutoFit, MaxWidth: sheetOpt.MaxWidth}
+	// The unparsed lines have none of the columns of the parsed ones.
+	unparsedOpt := *opt
+	unparsedOpt.Columns = nil
+	unparsedOpt.Where = nil
+	unparsedOpt.NumberFormats = nil
+	// Every log adds the counters of its columns to the counters sheet.
+	counters := &excel.SheetOption{Mode: sheetOpt.Mode, InPlace: true, AutoFit: sheetOpt.AutoFit, MaxWidth: sheetOpt.MaxWidth}
+	counterOpt := &excel.CellOption{DecimalPlaces: opt.DecimalPlaces, Round: opt.Round, Header: true, OnError: opt.OnError}
+	err = c2x.excel.PasteTxtFiles(jobs, workers, func(job *excel.PasteJob, err error) error {
+		if err == nil {
+			logFile, ok := job.Txt.(*logfile.LogFile)
+			if ok && inputOpt.UnmatchedSheet && logFile.Unmatched() > 0 {
+				err = c2x.excel.PasteTxtFile(logFile.UnmatchedFile(), unparsedSheet, &unparsedOpt, unparsed)
+				// Later inputs add their lines below the first ones.
+				unparsed = &excel.SheetOption{Mode: excel.PasteModeAppend, SkipHeader: true, InPlace: true}
+			}
+		}
+		if err == nil {
+			perfmonFile, ok := job.Txt.(*perfmon.PerfmonFile)
+			if ok && len(perfmonFile.Counters()) > 0 {
+				err = c2x.excel.PasteTxtFile(perfmonFile.CounterFile(job.Sheet), countersSheet, counterOpt, counters)
+				counters = &excel.SheetOption{Mode: excel.PasteModeAppend, SkipHeader: true, InPlace: true}
+			}
+		}
+		removes[job]()
+		delete(removes, job)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	rejected := c2x.excel.Rejected()
+	if opt.OnError == excel.OnErrorReport && len(rejected) > 0 {
+		err = c2x.reportRejected(rejected, errorFile)
 		if err != nil {
 			return err
 		}
@@ -101,5 +300,8 @@ func (c2x *Csv2Xlsx) convertExcel(config config.Config) error {
 	if err != nil {
 		return err
 	}
+	if opt.OnError == excel.OnErrorReport && len(rejected) > 0 {
+		return ErrRejectedRows.Details("rows", len(rejected))
+	}
 	return nil
 }
@@ -0,0 +1,196 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package csv2xlsx
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/kenita8/xlcmd/internal/app/csv2xlsx/config"
+	"github.com/kenita8/xlcmd/internal/pkg/excel"
+	"github.com/kenita8/xlcmd/internal/pkg/file/archive"
+	"github.com/kenita8/xlcmd/internal/pkg/file/csv"
+	"github.com/kenita8/xlcmd/internal/pkg/file/fixed"
+	"github.com/kenita8/xlcmd/internal/pkg/file/json"
+	"github.com/kenita8/xlcmd/internal/pkg/file/logfile"
+	"github.com/kenita8/xlcmd/internal/pkg/file/perfmon"
+	"github.com/kenita8/xlcmd/internal/pkg/file/txt"
+
+	"github.com/stretchr/testify/assert"
+	rawExcelize "github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+const badCsv = "a,b\n1,2\n3,4,5\n"
+
+func writeTestZip(t *testing.T, pathname string, member string, data string) {
+	f, err := os.Create(pathname)
+	assert.Nil(t, err)
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	w, err := zw.Create(member)
+	assert.Nil(t, err)
+	_, err = w.Write([]byte(data))
+	assert.Nil(t, err)
+	assert.Nil(t, zw.Close())
+}
+
+// withStdin reads data as the standard input until the test ends.
+func withStdin(t *testing.T, data string) {
+	pathname := filepath.Join(t.TempDir(), "stdin")
+	assert.Nil(t, os.WriteFile(pathname, []byte(data), 0644))
+	f, err := os.Open(pathname)
+	assert.Nil(t, err)
+	stdin := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		f.Close()
+	})
+}
+
+func TestNewTargetFileRejected(t *testing.T) {
+	dir := t.TempDir()
+	logs := filepath.Join(dir, "logs.zip")
+	writeTestZip(t, logs, "sub/a.csv", badCsv)
+	plain := filepath.Join(dir, "plain.csv")
+	assert.Nil(t, os.WriteFile(plain, []byte(badCsv), 0644))
+	testcases := []struct {
+		target config.Input
+		expect string
+	}{
+		{config.Input{Pathname: config.StdinName, Sheet: "stdin", Format: "csv"}, "-"},
+		{config.Input{Pathname: archive.Join(logs, "sub/a.csv"), Sheet: "a", Archive: logs, Member: "sub/a.csv"}, archive.Join(logs, "sub/a.csv")},
+		{config.Input{Pathname: plain, Sheet: "plain"}, plain},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			withStdin(t, badCsv)
+			input, remove, err := newTargetFile(tc.target, &config.InputOption{Encoding: "utf-8"})
+			assert.Nil(t, err)
+			defer remove()
+			assert.Equal(t, tc.expect, input.Filename())
+
+			e := excel.NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(filepath.Join(t.TempDir(), "output.xlsx")))
+			defer e.Close()
+			opt := &excel.CellOption{Header: true, OnError: excel.OnErrorReport}
+			assert.Nil(t, e.PasteTxtFile(input, tc.target.Sheet, opt, &excel.SheetOption{}))
+			rejected := e.Rejected()
+			assert.Len(t, rejected, 1)
+			assert.Equal(t, tc.expect, rejected[0].File)
+			assert.Equal(t, 3, rejected[0].Line)
+		})
+	}
+}
+
+func TestTargetOptionStacked(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.csv")
+	second := filepath.Join(dir, "b.csv")
+	assert.Nil(t, os.WriteFile(first, []byte("Name,Value\na,1\nb,2\n"), 0644))
+	assert.Nil(t, os.WriteFile(second, []byte("Name,Value\nc,3\n"), 0644))
+	testcases := []struct {
+		targets    []config.Input
+		expectRows [][]string
+	}{
+		{
+			[]config.Input{{Pathname: first, Sheet: "all"}, {Pathname: second, Sheet: "all", Stacked: true}},
+			[][]string{{"Name", "Value"}, {"a", "1"}, {"b", "2"}, {"c", "3"}},
+		},
+		{
+			[]config.Input{{Pathname: first, Sheet: "all"}, {Pathname: second, Sheet: "all", StartCell: "D1"}},
+			[][]string{{"Name", "Value", "", "Name", "Value"}, {"a", "1", "", "c", "3"}, {"b", "2"}},
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := excel.NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			opt := &excel.CellOption{InferTypes: true, Header: true}
+			sheetOpt := &excel.SheetOption{Mode: excel.PasteModeReplace}
+			jobs := []*excel.PasteJob{}
+			for _, target := range tc.targets {
+				input, remove, err := newTargetFile(target, &config.InputOption{Encoding: "utf-8"})
+				assert.Nil(t, err)
+				defer remove()
+				cellOpt, targetSheetOpt := targetOption(target, opt, sheetOpt, true)
+				jobs = append(jobs, &excel.PasteJob{Txt: input, Sheet: target.Sheet, CellOption: cellOpt, SheetOption: targetSheetOpt})
+			}
+			assert.Nil(t, e.PasteTxtFiles(jobs, 1, nil))
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			rows, err := f.GetRows("all")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectRows, rows)
+		})
+	}
+}
+
+func TestNewInputFile(t *testing.T) {
+	pattern := regexp.MustCompile(`(?P<msg>.*)`)
+	testcases := []struct {
+		pathname  string
+		opt       *config.InputOption
+		expect    txt.TxtFiler
+		expectErr error
+	}{
+		{"a.CSV", &config.InputOption{}, &csv.CsvFile{}, nil},
+		{"a.tsv", &config.InputOption{}, &csv.CsvFile{}, nil},
+		{"a.csv", &config.InputOption{Perfmon: true}, &perfmon.PerfmonFile{}, nil},
+		{"a.txt", &config.InputOption{Widths: &fixed.Spec{}}, &fixed.FixedFile{}, nil},
+		{"a.log", &config.InputOption{LogPattern: pattern}, &logfile.LogFile{}, nil},
+		{"a.dat", &config.InputOption{LogPattern: pattern}, &txt.TxtFile{}, nil},
+		{"a.json", &config.InputOption{}, &json.JsonFile{}, nil},
+		{"a.ndjson", &config.InputOption{}, &json.JsonFile{}, nil},
+		{"a.xlsx", &config.InputOption{}, nil, ErrInputFileExtension},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			input, err := newInputFile(tc.pathname, tc.opt)
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.IsType(t, tc.expect, input)
+		})
+	}
+}
+
+func TestWriteRejected(t *testing.T) {
+	pathname := filepath.Join(t.TempDir(), "errors.csv")
+	rejected := []excel.RejectedRow{
+		{File: "a.csv", Line: 3, Reason: "wrong number of fields"},
+		{File: "b.csv", Line: 2, Column: 4, Reason: "a, \"quoted\" reason"},
+	}
+	assert.Nil(t, writeRejected(pathname, rejected))
+	data, err := os.ReadFile(pathname)
+	assert.Nil(t, err)
+	assert.Equal(t, "file,line,column,reason\n"+
+		"a.csv,3,,wrong number of fields\n"+
+		"b.csv,2,4,\"a, \"\"quoted\"\" reason\"\n", string(data))
+
+	err = writeRejected(filepath.Join(t.TempDir(), "missing", "errors.csv"), rejected)
+	assert.ErrorIs(t, err, ErrWriteErrorFile)
+}
@@ -16,5 +16,8 @@ package csv2xlsx
 import "github.com/kenita8/errors"
 
 var (
-	ErrInputFileExtension = errors.New("file extension must be csv, tsv, or txt")
+	ErrInputFileExtension = errors.New("file extension must be csv, tsv, txt, log, dat, json, or ndjson")
+	ErrWriteErrorFile     = errors.New("unable to write rejected rows")
+	ErrRejectedRows       = errors.New("rows were rejected")
+	ErrInvalidStartCell   = errors.New("start cell is invalid")
 )
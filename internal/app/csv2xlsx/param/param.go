@@ -15,28 +15,128 @@ package param
 
 import (
 	"flag"
+	"strings"
 
+	"github.com/kenita8/xlcmd/internal/pkg/excel"
 	"go.uber.org/zap"
 )
 
 type Param interface {
 	Parse()
-	Input() string
+	Inputs() []string
 	XlsxFilename() string
+	Template() string
 	Extension() string
 	Depth() int
 	DecimalPlaces() int
+	Round() bool
+	NumberFormats() []string
 	Encoding() string
+	InferTypes() bool
+	DateLayouts() string
+	Header() bool
+	HeaderStyle() bool
+	FreezeHeader() bool
+	AutoFilter() bool
+	TableStyle() string
+	SheetName() string
+	Mode() string
+	SkipHeader() bool
+	JsonArrays() string
+	LogPattern() string
+	Unmatched() string
+	Widths() string
+	Format() string
+	Sheet() string
+	Include() []string
+	Exclude() []string
+	Sort() string
+	Jobs() int
+	Delimiter() string
+	Comment() string
+	LazyQuotes() bool
+	SkipRows() int
+	TrimSpace() bool
+	FieldsPerRecord() int
+	Locale() string
+	DecimalSep() string
+	ThousandsSep() string
+	OnError() string
+	ErrorFile() string
+	Split() string
+	Columns() string
+	Where() string
+	Manifest() string
+	StartCell() string
+	Preset() string
+	StripHost() bool
+	AutoFit() bool
+	MaxWidth() float64
 }
 
 type param struct {
-	log           *zap.Logger
-	input         string
-	xlsxFilename  string
-	ext           string
-	depth         int
-	decimalPlaces int
-	encoding      string
+	log             *zap.Logger
+	inputs          []string
+	xlsxFilename    string
+	template        string
+	ext             string
+	depth           int
+	decimalPlaces   int
+	round           bool
+	numberFormats   []string
+	encoding        string
+	inferTypes      bool
+	dateLayouts     string
+	header          bool
+	headerStyle     bool
+	freezeHeader    bool
+	autoFilter      bool
+	tableStyle      string
+	sheetName       string
+	mode            string
+	skipHeader      bool
+	jsonArrays      string
+	logPattern      string
+	unmatched       string
+	widths          string
+	format          string
+	sheet           string
+	include         []string
+	exclude         []string
+	sort            string
+	jobs            int
+	delimiter       string
+	comment         string
+	lazyQuotes      bool
+	skipRows        int
+	trimSpace       bool
+	fieldsPerRecord int
+	locale          string
+	decimalSep      string
+	thousandsSep    string
+	onError         string
+	errorFile       string
+	split           string
+	columns         string
+	where           string
+	manifest        string
+	startCell       string
+	preset          string
+	stripHost       bool
+	autoFit         bool
+	maxWidth        float64
+}
+
+// stringsFlag collects the values of a flag given more than once.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
 }
 
 func NewParam(log *zap.Logger) Param {
@@ -44,29 +144,126 @@ func NewParam(log *zap.Logger) Param {
 }
 
 func (p *param) Parse() {
-	input := flag.String("input", ".", "Set input files or directories to convert to Excel.")
+	inputs := stringsFlag{}
+	flag.Var(&inputs, "input", "Set an input file or directory to convert to Excel, or - for stdin. Repeat to add more, in sheet order. Default is the current directory.")
 	xlsxFilename := flag.String("xlsx", "output.xlsx", "Set output Excel file name.")
-	ext := flag.String("ext", "csv,tsv", "Set file extensions to search within input directories. csv, tsv, txt.")
+	template := flag.String("template", "", "Start the output as a copy of this workbook when it does not exist yet, keeping its styles, images, sheets and formulas, e.g. report.xltx. Inputs fill the sheets of the template that have their sheet name.")
+	ext := flag.String("ext", "csv,tsv", "Set file extensions to search within input directories. csv, tsv, txt, log, dat, json, ndjson.")
 	depth := flag.Int("depth", 0, "Set maximum directory depth for input.")
-	decimalPlaces := flag.Int("decimal-places", 2, "Set number of decimal places for numbers.")
-	encoding := flag.String("encoding", "UTF-8", "Set input file encoding(IANA-registered name).")
+	decimalPlaces := flag.Int("decimal-places", 2, "Show numbers with a fraction with this many decimal places, and whole numbers too in columns inferred as decimal numbers. The full value is stored. -1 shows them as stored.")
+	round := flag.Bool("round", false, "Store numbers rounded to --decimal-places, as earlier versions did, instead of only showing them rounded.")
+	numberFormats := stringsFlag{}
+	flag.Var(&numberFormats, "number-format", "Show a column with an Excel number format, as column=code, e.g. \"Rate=0.00%\", \"3=#,##0\" or \"/Time$/=yyyy-mm-dd hh:mm:ss\". The column is a 1-based index, header name or /regex/ of the written columns, counted after --columns picks them. Overrides --decimal-places and the formats of inferred types. Repeatable, the last format of a column wins. A manifest entry can add its own.")
+	encoding := flag.String("encoding", "UTF-8", "Set input file encoding(IANA-registered name, or auto to detect BOM and UTF-16).")
+	inferTypes := flag.Bool("infer-types", true, "Infer the type of each column (bool, integer, float, date or text).")
+	dateLayouts := flag.String("date-layouts", "", "Set date layouts for type inference, separated by semicolons. Go layout format, e.g. \"01/02/2006 15:04:05;2006-01-02\".")
+	header := flag.Bool("header", true, "Treat the first line of each input as a header row.")
+	headerStyle := flag.Bool("header-style", false, "Make the header row bold with a shaded background.")
+	freezeHeader := flag.Bool("freeze-header", false, "Freeze the header row.")
+	autoFilter := flag.Bool("autofilter", false, "Add an auto filter to the imported data.")
+	tableStyle := flag.String("as-table", "", "Convert the imported data to an Excel table with the given style, e.g. TableStyleMedium2.")
+	sheetName := flag.String("sheet-name", "{base}", "Set sheet name template. Fields: {base} file name, {stem} file name without extension, {dir} parent directory, {index} input number, {ext} extension.")
+	mode := flag.String("mode", "replace", "Set what to do when the sheet already exists. replace, append, skip.")
+	skipHeader := flag.Bool("skip-header", false, "Do not write the first line of the input when appending to an existing sheet.")
+	jsonArrays := flag.String("json-arrays", "join", "Set how JSON arrays are written. join (comma separated), explode (one row per element), json (JSON text).")
+	logPattern := flag.String("log-pattern", "", "Parse txt and log inputs with a regular expression whose named groups become columns, or a preset: combined, common, syslog.")
+	unmatched := flag.String("unmatched", "drop", "Set what to do with log lines that do not match the log pattern. drop, sheet (write them to the \"unparsed\" sheet).")
+	widths := flag.String("widths", "", "Read txt and dat inputs as fixed-width columns. Column widths separated by commas, e.g. \"10,8,20\", or a YAML column spec file (.yml).")
+	format := flag.String("format", "", "Set the format of stdin input. csv, tsv, txt, log, dat, json, ndjson.")
+	sheet := flag.String("sheet", "", "Set the sheet name for stdin input. Default is stdin.")
+	include := stringsFlag{}
+	flag.Var(&include, "include", "Only take files found in input directories that match this glob, e.g. \"**/2024-*/cpu*.tsv\". Repeatable.")
+	exclude := stringsFlag{}
+	flag.Var(&exclude, "exclude", "Leave out files found in input directories that match this glob, e.g. \"**/tmp/**\". Repeatable.")
+	sort := flag.String("sort", "", "Set the order of files found in each input directory. name, mtime, natural. Default is the directory walk order.")
+	jobs := flag.Int("jobs", 1, "Set the number of input files read and converted in parallel. Sheets are still added in input order.")
+	delimiter := flag.String("delimiter", "", "Set the field delimiter of csv and tsv inputs, e.g. \";\" or tab, or auto to guess it from the first lines. Default is , for csv and tab for tsv.")
+	comment := flag.String("comment", "", "Ignore lines of csv and tsv inputs that start with this character, e.g. \"#\".")
+	lazyQuotes := flag.Bool("lazy-quotes", false, "Accept quotes in unquoted fields and unescaped quotes in quoted fields of csv and tsv inputs.")
+	skipRows := flag.Int("skip-rows", 0, "Drop this many lines at the top of csv and tsv inputs before parsing.")
+	trimSpace := flag.Bool("trim-space", false, "Remove leading and trailing white space from fields of csv and tsv inputs.")
+	fieldsPerRecord := flag.Int("fields-per-record", 0, "Set the number of fields of every record of csv and tsv inputs. 0 takes it from the first record, -1 allows any.")
+	locale := flag.String("locale", "", "Read numbers written the way of this locale, e.g. de or fr_FR, including currency and percent. Default reads numbers as before.")
+	decimalSep := flag.String("decimal-sep", "", "Set the decimal separator of numbers, e.g. \",\". Overrides the locale.")
+	thousandsSep := flag.String("thousands-sep", "", "Set the thousands separators of numbers, e.g. \".\" or space. Overrides the locale.")
+	onError := flag.String("on-error", "fail", "Set what to do with rows that cannot be read or written: malformed csv, tsv, and ndjson records, and cells longer than 32767 characters in any input. Other malformed input, such as a broken JSON array, always fails. fail, skip, report (write them to the \"_errors\" sheet or the error file, and exit with an error).")
+	errorFile := flag.String("error-file", "", "Write the rows rejected in report mode to this CSV file instead of the \"_errors\" sheet.")
+	split := flag.String("split", "sheets", "Set where an input goes on when it has more rows or columns than a sheet holds. sheets (\"cpu.tsv (2)\", ...), workbooks (\"output (2).xlsx\", ...). The header row is repeated on each.")
+	columns := flag.String("columns", "", "Write only these columns, in this order. 1-based indexes of the input columns, header names or /regex/ separated by commas, each optionally followed by =new header, e.g. \"1=Time,/Processor Time$/,Memory\". Or a YAML column file (.yml).")
+	where := flag.String("where", "", "Only write rows that meet this condition, e.g. 'col(\"% Processor Time\") > 80 && col(1) >= \"07/15/2024\"'. col(n) is the 1-based input column n, counted like --columns before it picks the columns, so col(1) is the first column. col(\"name\") is the column with that header. Compare with == != < <= > >= to numbers, strings, dates or columns, match with =~ !~, and combine with && || ! and parentheses.")
+	manifest := flag.String("manifest", "", "Read the inputs from a YAML manifest instead of --input. Each entry sets an input file or glob, its sheet and start cell, encoding, csv dialect and header, and entries on the same sheet become blocks of it.")
+	startCell := flag.String("start-cell", "", "Write each input from this cell instead of A1, e.g. B5, keeping the cells above and left of it on an existing sheet such as a title block. The header style, table and auto filter start there too. A manifest entry can set its own.")
+	preset := flag.String("preset", "", "Read csv and tsv inputs the way of a known source. perfmon (Windows Performance Monitor PDH-CSV and PDH-TSV logs: the first row is always the header, types are always inferred so timestamps become datetimes, also with --date-layouts, \" \" samples become empty cells, and the parts of each counter path go to the \"counters\" sheet).")
+	stripHost := flag.Bool("strip-host", false, "Leave the host out of the counter paths of the header row, e.g. \\\\LAPTOP\\Memory\\Available MBytes becomes Memory\\Available MBytes. Requires --preset perfmon.")
+	autoFit := flag.Bool("autofit", false, "Fit the width of the columns of each input to their text as shown, wide East Asian characters counting as two. Columns given a --number-format are measured as written.")
+	maxWidth := flag.Float64("max-width", excel.DefaultMaxWidth, "Cap the widths set by --autofit, in characters.")
 	flag.Parse()
-	p.input = *input
+	p.inputs = inputs
+	if len(p.inputs) <= 0 {
+		p.inputs = []string{"."}
+	}
 	p.xlsxFilename = *xlsxFilename
+	p.template = *template
 	p.ext = *ext
 	p.depth = *depth
 	p.decimalPlaces = *decimalPlaces
+	p.round = *round
+	p.numberFormats = numberFormats
 	p.encoding = *encoding
+	p.inferTypes = *inferTypes
+	p.dateLayouts = *dateLayouts
+	p.header = *header
+	p.headerStyle = *headerStyle
+	p.freezeHeader = *freezeHeader
+	p.autoFilter = *autoFilter
+	p.tableStyle = *tableStyle
+	p.sheetName = *sheetName
+	p.mode = *mode
+	p.skipHeader = *skipHeader
+	p.jsonArrays = *jsonArrays
+	p.logPattern = *logPattern
+	p.unmatched = *unmatched
+	p.widths = *widths
+	p.format = *format
+	p.sheet = *sheet
+	p.include = include
+	p.exclude = exclude
+	p.sort = *sort
+	p.jobs = *jobs
+	p.delimiter = *delimiter
+	p.comment = *comment
+	p.lazyQuotes = *lazyQuotes
+	p.skipRows = *skipRows
+	p.trimSpace = *trimSpace
+	p.fieldsPerRecord = *fieldsPerRecord
+	p.locale = *locale
+	p.decimalSep = *decimalSep
+	p.thousandsSep = *thousandsSep
+	p.onError = *onError
+	p.errorFile = *errorFile
+	p.split = *split
+	p.columns = *columns
+	p.where = *where
+	p.manifest = *manifest
+	p.startCell = *startCell
+	p.preset = *preset
+	p.stripHost = *stripHost
+	p.autoFit = *autoFit
+	p.maxWidth = *maxWidth
 }
 
-func (p *param) Input() string {
-	return p.input
+func (p *param) Inputs() []string {
+	return p.inputs
 }
 
 func (p *param) XlsxFilename() string {
 	return p.xlsxFilename
 }
 
+func (p *param) Template() string {
+	return p.template
+}
+
 func (p *param) Extension() string {
 	return p.ext
 }
@@ -79,6 +276,174 @@ func (p *param) DecimalPlaces() int {
 	return p.decimalPlaces
 }
 
+func (p *param) Round() bool {
+	return p.round
+}
+
+func (p *param) NumberFormats() []string {
+	return p.numberFormats
+}
+
 func (p *param) Encoding() string {
 	return p.encoding
 }
+
+func (p *param) InferTypes() bool {
+	return p.inferTypes
+}
+
+func (p *param) DateLayouts() string {
+	return p.dateLayouts
+}
+
+func (p *param) Header() bool {
+	return p.header
+}
+
+func (p *param) HeaderStyle() bool {
+	return p.headerStyle
+}
+
+func (p *param) FreezeHeader() bool {
+	return p.freezeHeader
+}
+
+func (p *param) AutoFilter() bool {
+	return p.autoFilter
+}
+
+func (p *param) TableStyle() string {
+	return p.tableStyle
+}
+
+func (p *param) SheetName() string {
+	return p.sheetName
+}
+
+func (p *param) Mode() string {
+	return p.mode
+}
+
+func (p *param) SkipHeader() bool {
+	return p.skipHeader
+}
+
+func (p *param) JsonArrays() string {
+	return p.jsonArrays
+}
+
+func (p *param) LogPattern() string {
+	return p.logPattern
+}
+
+func (p *param) Unmatched() string {
+	return p.unmatched
+}
+
+func (p *param) Widths() string {
+	return p.widths
+}
+
+func (p *param) Format() string {
+	return p.format
+}
+
+func (p *param) Sheet() string {
+	return p.sheet
+}
+
+func (p *param) Include() []string {
+	return p.include
+}
+
+func (p *param) Exclude() []string {
+	return p.exclude
+}
+
+func (p *param) Sort() string {
+	return p.sort
+}
+
+func (p *param) Jobs() int {
+	return p.jobs
+}
+
+func (p *param) Delimiter() string {
+	return p.delimiter
+}
+
+func (p *param) Comment() string {
+	return p.comment
+}
+
+func (p *param) LazyQuotes() bool {
+	return p.lazyQuotes
+}
+
+func (p *param) SkipRows() int {
+	return p.skipRows
+}
+
+func (p *param) TrimSpace() bool {
+	return p.trimSpace
+}
+
+func (p *param) FieldsPerRecord() int {
+	return p.fieldsPerRecord
+}
+
+func (p *param) Locale() string {
+	return p.locale
+}
+
+func (p *param) DecimalSep() string {
+	return p.decimalSep
+}
+
+func (p *param) ThousandsSep() string {
+	return p.thousandsSep
+}
+
+func (p *param) OnError() string {
+	return p.onError
+}
+
+func (p *param) ErrorFile() string {
+	return p.errorFile
+}
+
+func (p *param) Split() string {
+	return p.split
+}
+
+func (p *param) Columns() string {
+	return p.columns
+}
+
+func (p *param) Where() string {
+	return p.where
+}
+
+func (p *param) Manifest() string {
+	return p.manifest
+}
+
+func (p *param) StartCell() string {
+	return p.startCell
+}
+
+func (p *param) Preset() string {
+	return p.preset
+}
+
+func (p *param) StripHost() bool {
+	return p.stripHost
+}
+
+func (p *param) AutoFit() bool {
+	return p.autoFit
+}
+
+func (p *param) MaxWidth() float64 {
+	return p.maxWidth
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package csv2xlsx
+
+import (
+	rawCsv "encoding/csv"
+	"os"
+
+	"github.com/kenita8/xlcmd/internal/app/csv2xlsx/config"
+	"github.com/kenita8/xlcmd/internal/pkg/excel"
+	"github.com/kenita8/xlcmd/internal/pkg/file/csv"
+)
+
+const (
+	errorsSheet = config.ErrorsSheet
+)
+
+// reportRejected writes the rows left out to errorFile, or to the errors sheet
+// when errorFile is empty.
+func (c2x *Csv2Xlsx) reportRejected(rejected []excel.RejectedRow, errorFile string) error {
+	if len(errorFile) > 0 {
+		return writeRejected(errorFile, rejected)
+	}
+	fp, err := os.CreateTemp("", "*-"+errorsSheet+".csv")
+	if err != nil {
+		return ErrWriteErrorFile.Details("file", errorsSheet).Wrap(err)
+	}
+	pathname := fp.Name()
+	fp.Close()
+	defer os.Remove(pathname)
+	err = writeRejected(pathname, rejected)
+	if err != nil {
+		return err
+	}
+	sheetOpt := &excel.SheetOption{HeaderStyle: true, FreezeHeader: true}
+	return c2x.excel.PasteTxtFile(csv.NewCsvFile(pathname, "UTF-8"), errorsSheet, nil, sheetOpt)
+}
+
+func writeRejected(pathname string, rejected []excel.RejectedRow) error {
+	fp, err := os.Create(pathname)
+	if err != nil {
+		return ErrWriteErrorFile.Details("file", pathname).Wrap(err)
+	}
+	defer fp.Close()
+	w := rawCsv.NewWriter(fp)
+	w.Write(excel.RejectedHeader)
+	for _, row := range rejected {
+		w.Write(row.Values())
+	}
+	w.Flush()
+	err = w.Error()
+	if err != nil {
+		return ErrWriteErrorFile.Details("file", pathname).Wrap(err)
+	}
+	return nil
+}
@@ -25,6 +25,7 @@ type Config interface {
 	ChartType() excelize.ChartType
 	SheetName() (*regexp.Regexp, error)
 	XlsxFilename() string
+	Template() string
 }
 
 type config struct {
@@ -52,3 +53,7 @@ func (c *config) SheetName() (*regexp.Regexp, error) {
 func (c *config) XlsxFilename() string {
 	return c.param.XlsxFilename()
 }
+
+func (c *config) Template() string {
+	return c.param.Template()
+}
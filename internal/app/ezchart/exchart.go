@@ -30,7 +30,7 @@ type EzChart struct {
 }
 
 type Excel interface {
-	Open(filename string) error
+	OpenWithTemplate(filename string, template string) error
 	GetSheetList() []string
 	MaxRow(sheet string) (int, error)
 	MaxCol(sheet string) (int, error)
@@ -134,7 +134,7 @@ func (x *EzChart) generateEzChart(config config.Config) error {
 	chartType := config.ChartType()
 	xlsxFilename := config.XlsxFilename()
 
-	err = x.excel.Open(xlsxFilename)
+	err = x.excel.OpenWithTemplate(xlsxFilename, config.Template())
 	if err != nil {
 		return err
 	}
@@ -24,6 +24,7 @@ type Param interface {
 	ChartType() string
 	SheetName() string
 	XlsxFilename() string
+	Template() string
 }
 
 type param struct {
@@ -31,6 +32,7 @@ type param struct {
 	chartType    string
 	sheetName    string
 	xlsxFilename string
+	template     string
 }
 
 func NewParam(log *zap.Logger) Param {
@@ -41,10 +43,12 @@ func (p *param) Parse() {
 	chartType := flag.String("type", `Line`, "Set chart type to create.")
 	sheetName := flag.String("sheet", `.+\.(csv|tsv)$`, "Set the sheet name for the graph. Regex allowed.")
 	xlsxFilename := flag.String("xlsx", "output.xlsx", "Set output Excel file name.")
+	template := flag.String("template", "", "Start the output as a copy of this workbook when it does not exist yet, keeping its styles, images, sheets and formulas, e.g. report.xltx.")
 	flag.Parse()
 	p.chartType = *chartType
 	p.sheetName = *sheetName
 	p.xlsxFilename = *xlsxFilename
+	p.template = *template
 }
 
 func (p *param) ChartType() string {
@@ -58,3 +62,7 @@ func (p *param) SheetName() string {
 func (p *param) XlsxFilename() string {
 	return p.xlsxFilename
 }
+
+func (p *param) Template() string {
+	return p.template
+}
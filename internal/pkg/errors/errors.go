@@ -0,0 +1,99 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package errors has the package errors of the packages whose code may run on
+// several goroutines at once. It works like github.com/kenita8/errors, except
+// that Details returns a copy of the error instead of changing it.
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ConstError is a package error, or a copy of one with details. A copy is
+// the package error for errors.Is.
+type ConstError struct {
+	text    string
+	details []string
+	// origin is the package error of a copy.
+	origin *ConstError
+}
+
+func New(text string) *ConstError {
+	return &ConstError{text: text}
+}
+
+func (c *ConstError) Error() string {
+	text := c.text
+	if c.details != nil {
+		text += "("
+		text += strings.Join(c.details, ", ")
+		text += ")"
+	}
+	return text
+}
+
+func (c *ConstError) Is(target error) bool {
+	return c.origin != nil && target == error(c.origin)
+}
+
+func (c *ConstError) Wrap(err error) *WrappedError {
+	return &WrappedError{wrapper: c, wrapped: err}
+}
+
+// Details returns a copy of c with the key value pairs.
+func (c *ConstError) Details(kv ...any) *ConstError {
+	origin := c
+	if c.origin != nil {
+		origin = c.origin
+	}
+	details := []string{}
+	for i := 0; i < len(kv); i += 2 {
+		key := kv[i]
+		var value any
+		if i+1 < len(kv) {
+			value = kv[i+1]
+		}
+		details = append(details, fmt.Sprintf("%v=%v", key, value))
+	}
+	return &ConstError{text: c.text, details: details, origin: origin}
+}
+
+type WrappedError struct {
+	wrapper error
+	wrapped error
+}
+
+func (we *WrappedError) Error() string {
+	if we.wrapped != nil {
+		return we.wrapper.Error() + ": " + we.wrapped.Error()
+	}
+	return we.wrapper.Error()
+}
+
+func (we *WrappedError) Wrap(wrappederr error) error {
+	return &WrappedError{
+		wrapper: we,
+		wrapped: wrappederr,
+	}
+}
+
+func (we *WrappedError) Unwrap() error {
+	return we.wrapped
+}
+
+func (we *WrappedError) Is(target error) bool {
+	return we == target || errors.Is(we.wrapper, target) || errors.Is(we.wrapped, target)
+}
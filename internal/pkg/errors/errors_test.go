@@ -0,0 +1,90 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ErrOriginal = errors.New("error original")
+	ErrNotFound = New("not found")
+	Err1        = New("err 1")
+	Err2        = New("err 2")
+)
+
+func TestWrap(t *testing.T) {
+	testcases := []struct {
+		err          error
+		expectIs     []error
+		expectIsNot  []error
+		expectString string
+	}{
+		{
+			err:          Err1.Wrap(ErrNotFound),
+			expectIs:     []error{Err1, ErrNotFound},
+			expectIsNot:  []error{Err2},
+			expectString: "err 1: not found",
+		},
+		{
+			err:          Err1.Details("k11", "v11", "k12").Wrap(Err2.Details("k21", "v21").Wrap(ErrOriginal)),
+			expectIs:     []error{Err1, Err2, ErrOriginal},
+			expectIsNot:  []error{ErrNotFound},
+			expectString: "err 1(k11=v11, k12=<nil>): err 2(k21=v21): error original",
+		},
+		{
+			err:          Err1.Details("k", "v").Details("k", "w"),
+			expectIs:     []error{Err1},
+			expectIsNot:  []error{Err2},
+			expectString: "err 1(k=w)",
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			for _, target := range tc.expectIs {
+				assert.True(t, errors.Is(tc.err, target), target)
+			}
+			for _, target := range tc.expectIsNot {
+				assert.False(t, errors.Is(tc.err, target), target)
+			}
+			assert.Equal(t, tc.expectString, tc.err.Error())
+		})
+	}
+	assert.Equal(t, "err 1", Err1.Error())
+}
+
+// TestDetailsGoroutines adds details to the same error on several goroutines.
+// Run it with -race.
+func TestDetailsGoroutines(t *testing.T) {
+	errs := make([]error, 10)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = ErrNotFound.Details("file", i)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		assert.Equal(t, fmt.Sprintf("not found(file=%d)", i), err.Error())
+		assert.True(t, errors.Is(err, ErrNotFound))
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+
+	rawExcelize "github.com/xuri/excelize/v2"
+)
+
+const (
+	// DefaultMaxWidth caps the width of a fitted column, in characters.
+	DefaultMaxWidth = 60
+	// MaxColumnWidth is the widest a column can be.
+	MaxColumnWidth = rawExcelize.MaxColumnWidth
+	// widthPadding is the room left around the text of a fitted column.
+	widthPadding = 2
+	// filterButtonWidth is the room the drop-down button of an auto filter
+	// takes in a header cell.
+	filterButtonWidth = 2
+)
+
+// textWidth is the width of the widest line of text, a wide character two.
+func textWidth(text string) int {
+	width := 0
+	for _, line := range strings.Split(text, "\n") {
+		width = max(width, file.StringWidth(line))
+	}
+	return width
+}
+
+// shownText is value as shown with decimals, whole numbers too with whole.
+func shownText(value string, opt *CellOption, whole bool) string {
+	if opt.Number != nil || len(decimalsNumFmt(opt)) <= 0 {
+		return value
+	}
+	v := cellValue(value, opt)
+	if formatted, ok := v.(formattedValue); ok {
+		v = formatted.value
+	} else if !whole {
+		return value
+	}
+	valuef, ok := v.(float64)
+	if !ok {
+		return value
+	}
+	return strconv.FormatFloat(valuef, 'f', opt.DecimalPlaces, 64)
+}
+
+// CheckMaxWidth returns ErrInvalidMaxWidth when maxWidth is not a width a
+// fitted column can be capped at.
+func CheckMaxWidth(maxWidth float64) error {
+	if maxWidth <= 0 || maxWidth > MaxColumnWidth {
+		return ErrInvalidMaxWidth.Details("max-width", maxWidth)
+	}
+	return nil
+}
+
+// columnWidth is the width of a column chars wide, capped at maxWidth.
+func columnWidth(chars int, maxWidth float64) float64 {
+	if maxWidth <= 0 {
+		maxWidth = DefaultMaxWidth
+	}
+	return min(float64(chars+widthPadding), maxWidth, MaxColumnWidth)
+}
+
+// fitWidth widens width to what col was fitted to for another block.
+func (e *Excel) fitWidth(sheet string, col int, width float64) float64 {
+	if e.widths == nil {
+		e.widths = map[string]map[int]float64{}
+	}
+	key := strings.ToLower(sheet)
+	if e.widths[key] == nil {
+		e.widths[key] = map[int]float64{}
+	}
+	width = max(width, e.widths[key][col])
+	e.widths[key][col] = width
+	return width
+}
+
+// fitColumns fits the columns of the part, input columns cols, to profile.
+func (p *sheetPart) fitColumns(profile *TxtProfile, cols [2]int) error {
+	filter := p.sheetOpt.AutoFilter || len(p.sheetOpt.TableStyle) > 0
+	for i := cols[0]; i < min(cols[1], profile.Cols); i++ {
+		chars := 0
+		if i < len(profile.Widths) {
+			chars = profile.Widths[i]
+		}
+		if i < len(profile.HeaderWidths) && profile.HeaderWidths[i] > 0 {
+			header := profile.HeaderWidths[i]
+			if filter {
+				header += filterButtonWidth
+			}
+			chars = max(chars, header)
+		}
+		if chars <= 0 {
+			continue
+		}
+		col := p.left + i - cols[0]
+		width := p.e.fitWidth(p.sheet, col, columnWidth(chars, p.sheetOpt.MaxWidth))
+		err := p.sw.SetColWidth(col, col, width)
+		if err != nil {
+			return ErrSetColWidth.Details("sheet", p.sheet, "col", col).Wrap(err)
+		}
+	}
+	return nil
+}
+
+// AutoFit sets the width of the columns left to right of a sheet to fit the
+// text of their cells as it is shown, up to maxWidth, or DefaultMaxWidth when
+// it is zero. An empty column keeps its width. A sheet written through a
+// stream writer cannot be read again before the workbook is saved, so an
+// input is fitted as it is written with SheetOption.AutoFit instead.
+func (e *Excel) AutoFit(sheet string, left int, right int, maxWidth float64) error {
+	if e.xlFile == nil {
+		return ErrNotOpened
+	}
+	cols, err := e.xlFile.Cols(sheet)
+	if err != nil {
+		return ErrReadSheet.Details("sheet", sheet).Wrap(err)
+	}
+	for col := 1; col <= right && cols.Next(); col++ {
+		if col < left {
+			continue
+		}
+		values, err := cols.Rows()
+		if err != nil {
+			return ErrReadSheet.Details("sheet", sheet).Wrap(err)
+		}
+		chars := 0
+		for _, value := range values {
+			chars = max(chars, textWidth(value))
+		}
+		if chars <= 0 {
+			continue
+		}
+		name, err := rawExcelize.ColumnNumberToName(col)
+		if err != nil {
+			return ErrSetColWidth.Details("sheet", sheet, "col", col).Wrap(err)
+		}
+		err = e.xlFile.SetColWidth(sheet, name, name, columnWidth(chars, maxWidth))
+		if err != nil {
+			return ErrSetColWidth.Details("sheet", sheet, "col", col).Wrap(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kenita8/xlcmd/internal/pkg/excel/excelize"
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+	"github.com/kenita8/xlcmd/internal/pkg/file/csv"
+
+	"github.com/stretchr/testify/assert"
+	rawExcelize "github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+func TestPasteTxtFileAutoFit(t *testing.T) {
+	input := writeTestFile(t, "data.csv", "Id,Name,Note\n1,東京都,"+strings.Repeat("x", 100)+"\n22,a,\n")
+	defaultWidth := 9.140625
+	testcases := []struct {
+		sheetOpt     *SheetOption
+		expectWidths map[string]float64
+	}{
+		{
+			sheetOpt:     &SheetOption{AutoFit: true},
+			expectWidths: map[string]float64{"A": 4, "B": 8, "C": 60, "D": defaultWidth},
+		},
+		{
+			sheetOpt:     &SheetOption{AutoFit: true, AutoFilter: true, MaxWidth: 20},
+			expectWidths: map[string]float64{"A": 6, "B": 8, "C": 20},
+		},
+		{
+			sheetOpt:     &SheetOption{AutoFit: true, StartCell: "B3", InPlace: true},
+			expectWidths: map[string]float64{"A": defaultWidth, "B": 4, "C": 8, "D": 60},
+		},
+		{
+			sheetOpt:     &SheetOption{},
+			expectWidths: map[string]float64{"A": defaultWidth, "B": defaultWidth, "C": defaultWidth},
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			opt := &CellOption{InferTypes: true, Header: true}
+			assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "data", opt, tc.sheetOpt))
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			for col, expect := range tc.expectWidths {
+				width, err := f.GetColWidth("data", col)
+				assert.Nil(t, err)
+				assert.Equal(t, expect, width, col)
+			}
+		})
+	}
+}
+
+func TestPasteTxtFileAutoFitShown(t *testing.T) {
+	input := writeTestFile(t, "data.csv", "A,B,C\n1.5,01/Feb/2024:10:00:00 +0000,7\n12,02/Feb/2024:10:00:00 +0000,8\n")
+	testcases := []struct {
+		opt          *CellOption
+		expectWidths map[string]float64
+	}{
+		{
+			opt:          &CellOption{InferTypes: true, Header: true, DecimalPlaces: 2},
+			expectWidths: map[string]float64{"A": 7, "B": 21, "C": 3},
+		},
+		{
+			opt:          &CellOption{Header: true, DecimalPlaces: 2},
+			expectWidths: map[string]float64{"A": 6, "B": 28, "C": 3},
+		},
+		{
+			opt:          &CellOption{InferTypes: true, Header: true, DecimalPlaces: -1},
+			expectWidths: map[string]float64{"A": 5, "B": 21, "C": 3},
+		},
+		{
+			opt: &CellOption{InferTypes: true, Header: true, DecimalPlaces: 2,
+				NumberFormats: []NumberFormat{{Column: ColumnSelector{Index: 1}, Code: "0"}}},
+			expectWidths: map[string]float64{"A": 5, "B": 21, "C": 3},
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "data", tc.opt, &SheetOption{AutoFit: true}))
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			for col, expect := range tc.expectWidths {
+				width, err := f.GetColWidth("data", col)
+				assert.Nil(t, err)
+				assert.Equal(t, expect, width, col)
+			}
+		})
+	}
+}
+
+func TestAutoFit(t *testing.T) {
+	testcases := []struct {
+		left         int
+		right        int
+		maxWidth     float64
+		expectWidths map[string]float64
+	}{
+		{1, 3, 0, map[string]float64{"A": 7, "B": 16, "C": 9.140625}},
+		{2, 2, 10, map[string]float64{"A": 9.140625, "B": 10}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			assert.Nil(t, e.SetCellValue("Total", "Sheet1", 1, 1, nil))
+			assert.Nil(t, e.SetCellValue("12.5%", "Sheet1", 1, 2, &CellOption{DecimalPlaces: 2, Number: &NumberLocale{Decimal: '.'}}))
+			assert.Nil(t, e.SetCellValue("日本語テキスト\nab", "Sheet1", 2, 3, nil))
+			assert.Nil(t, e.AutoFit("Sheet1", tc.left, tc.right, tc.maxWidth))
+			for col, expect := range tc.expectWidths {
+				width, err := e.xlFile.(*rawExcelize.File).GetColWidth("Sheet1", col)
+				assert.Nil(t, err)
+				assert.Equal(t, expect, width, col)
+			}
+			e.Close()
+		})
+	}
+}
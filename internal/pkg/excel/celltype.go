@@ -0,0 +1,361 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kenita8/xlcmd/internal/pkg/file/txt"
+)
+
+type CellType int
+
+const (
+	CellTypeBool CellType = iota
+	CellTypeInt
+	CellTypeFloat
+	CellTypeDate
+	CellTypeText
+)
+
+const (
+	// Excel keeps 15 significant digits, longer integers are stored as text.
+	maxIntDigits = 15
+	dayDuration  = 24 * time.Hour
+	allCellTypes = 1<<CellTypeBool | 1<<CellTypeInt | 1<<CellTypeFloat | 1<<CellTypeDate
+)
+
+var (
+	DefaultDateLayouts = []string{
+		"01/02/2006 15:04:05",
+		"2006-01-02 15:04:05",
+		"2006/01/02 15:04:05",
+		"2006-01-02T15:04:05Z07:00",
+		"2006-01-02T15:04:05",
+		"01/02/2006",
+		"2006-01-02",
+		"2006/01/02",
+		"02/Jan/2006:15:04:05 -0700",
+		"15:04:05",
+	}
+	intPattern   = regexp.MustCompile(`^[+-]?(0|[1-9][0-9]*)$`)
+	floatPattern = regexp.MustCompile(`^[+-]?(0|[1-9][0-9]*)?(\.[0-9]*)?([eE][+-]?[0-9]+)?$`)
+)
+
+// ColumnType is the type decided for every data cell of a column.
+type ColumnType struct {
+	Type   CellType
+	Layout string
+	NumFmt string
+}
+
+type columnGuess struct {
+	types   int
+	layouts []string
+	number  *NumberLocale
+	frac    bool
+}
+
+func newColumnGuess(layouts []string, number *NumberLocale) *columnGuess {
+	return &columnGuess{
+		types:   allCellTypes,
+		layouts: layouts,
+		number:  number,
+	}
+}
+
+func (g *columnGuess) has(t CellType) bool {
+	return g.types&(1<<t) != 0
+}
+
+func (g *columnGuess) drop(t CellType) {
+	g.types &^= 1 << t
+}
+
+func (g *columnGuess) add(value string) {
+	value = strings.TrimSpace(value)
+	if len(value) <= 0 {
+		return
+	}
+	if g.has(CellTypeBool) && !isBool(value) {
+		g.drop(CellTypeBool)
+	}
+	if g.has(CellTypeInt) && !isInt(value) {
+		g.drop(CellTypeInt)
+	}
+	if g.has(CellTypeFloat) && !isNumber(value, g.number) {
+		g.drop(CellTypeFloat)
+	}
+	if g.has(CellTypeDate) {
+		layouts := g.layouts[:0:0]
+		for _, layout := range g.layouts {
+			t, err := time.Parse(layout, value)
+			if err == nil {
+				layouts = append(layouts, layout)
+				g.frac = g.frac || t.Nanosecond() != 0
+			}
+		}
+		g.layouts = layouts
+		if len(layouts) <= 0 {
+			g.drop(CellTypeDate)
+		}
+	}
+}
+
+func (g *columnGuess) columnType() ColumnType {
+	switch {
+	case g.types == allCellTypes:
+		return ColumnType{Type: CellTypeText}
+	case g.has(CellTypeBool):
+		return ColumnType{Type: CellTypeBool}
+	case g.has(CellTypeInt):
+		return ColumnType{Type: CellTypeInt, NumFmt: "0"}
+	case g.has(CellTypeFloat):
+		return ColumnType{Type: CellTypeFloat}
+	case g.has(CellTypeDate):
+		return ColumnType{Type: CellTypeDate, Layout: g.layouts[0], NumFmt: dateNumFmt(g.layouts[0], g.frac)}
+	}
+	return ColumnType{Type: CellTypeText}
+}
+
+func isBool(value string) bool {
+	return strings.EqualFold(value, "true") || strings.EqualFold(value, "false")
+}
+
+func isInt(value string) bool {
+	return intPattern.MatchString(value) && len(strings.TrimLeft(value, "+-")) <= maxIntDigits
+}
+
+func isFloat(value string) bool {
+	if !floatPattern.MatchString(value) || strings.IndexAny(value, "0123456789") < 0 {
+		return false
+	}
+	if intPattern.MatchString(value) && !isInt(value) {
+		return false
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+// isNumber is isFloat, or whether value is a number written with number.
+func isNumber(value string, number *NumberLocale) bool {
+	if number == nil {
+		return isFloat(value)
+	}
+	if intPattern.MatchString(value) && !isInt(value) {
+		return false
+	}
+	_, ok := parseLocaleNumber(value, number)
+	return ok
+}
+
+func hasDate(layout string) bool {
+	return strings.Contains(layout, "2006") || strings.Contains(layout, "06")
+}
+
+func hasTime(layout string) bool {
+	return strings.Contains(layout, "04")
+}
+
+func dateNumFmt(layout string, frac bool) string {
+	numFmt := ""
+	if hasDate(layout) {
+		numFmt = "yyyy-mm-dd"
+	}
+	if hasTime(layout) || !hasDate(layout) {
+		numFmt = strings.TrimSpace(numFmt + " hh:mm:ss")
+		if frac {
+			numFmt += ".000"
+		}
+	}
+	return numFmt
+}
+
+// Value converts a cell text to the Go value written for the column type.
+// The second result is false when the text does not fit the type.
+func (c *ColumnType) Value(value string, opt *CellOption) (interface{}, bool) {
+	trimmed := strings.TrimSpace(value)
+	switch c.Type {
+	case CellTypeBool:
+		if isBool(trimmed) {
+			return strings.EqualFold(trimmed, "true"), true
+		}
+	case CellTypeInt:
+		if isInt(trimmed) {
+			valuei, err := strconv.ParseInt(trimmed, 10, 64)
+			return valuei, err == nil
+		}
+	case CellTypeFloat:
+		var number *NumberLocale
+		if opt != nil {
+			number = opt.Number
+		}
+		if isNumber(trimmed, number) {
+			typed := cellValue(trimmed, opt)
+			// A whole number shows the decimals of the rest of the column.
+			if valuef, ok := typed.(float64); ok && number == nil && len(decimalsNumFmt(opt)) > 0 {
+				return formattedValue{value: valuef, numFmt: decimalsNumFmt(opt)}, true
+			}
+			return typed, true
+		}
+	case CellTypeDate:
+		t, err := time.Parse(c.Layout, trimmed)
+		if err != nil {
+			return nil, false
+		}
+		if !hasDate(c.Layout) {
+			sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+				time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+			return float64(sinceMidnight) / float64(dayDuration), true
+		}
+		return t, true
+	case CellTypeText:
+		return value, true
+	}
+	return nil, false
+}
+
+// TxtProfile is what a first pass over an input learns before it is written.
+type TxtProfile struct {
+	Rows  int
+	Cols  int
+	Types []ColumnType
+	// Widths and HeaderWidths are the widest text of each column in the data
+	// rows and the header row, when the profile takes widths. A data cell is
+	// measured as it is shown, except that a column with a number format of
+	// NumberFormats is measured as it is written.
+	Widths       []int
+	HeaderWidths []int
+	// textWidths are the widest text of each column as it is written, and
+	// floatWidths as it is shown in a column of decimal numbers.
+	textWidths  []int
+	floatWidths []int
+}
+
+// ProfileTxtFile reads txt once and counts its rows and columns. With
+// opt.InferTypes it also decides the type of every column from all non-empty
+// values, leaving out the header row when opt.Header is set.
+func ProfileTxtFile(txt txt.TxtFiler, opt *CellOption) (*TxtProfile, error) {
+	profile, err := profileTxtFile(txt, opt, false)
+	return profile, wrapReadError(txt, err)
+}
+
+// profileTxtFile returns read failures as a readError.
+func profileTxtFile(txt txt.TxtFiler, opt *CellOption, widths bool) (*TxtProfile, error) {
+	err := txt.OpenReadMode()
+	if err != nil {
+		return nil, &readError{err: err}
+	}
+	defer txt.Close()
+	infer := opt != nil && opt.InferTypes
+	layouts := DefaultDateLayouts
+	if opt != nil && len(opt.DateLayouts) > 0 {
+		layouts = opt.DateLayouts
+	}
+	profile := &TxtProfile{}
+	guesses := []*columnGuess{}
+	reader := newRowReader(txt, opt)
+	formats := newNumberFormats(opt)
+	for {
+		values, rejected, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rejected != nil {
+			// The row is left out of the sheet, or the writer fails on it.
+			continue
+		}
+		profile.Rows++
+		profile.Cols = max(profile.Cols, len(values))
+		if widths {
+			formats.resolveRow(values, reader.first())
+			profile.fit(values, reader.first() && opt != nil && opt.Header, opt, formats)
+		}
+		if !infer || (reader.first() && opt.Header) {
+			continue
+		}
+		for len(guesses) < len(values) {
+			guesses = append(guesses, newColumnGuess(layouts, opt.Number))
+		}
+		for i, value := range values {
+			guesses[i].add(value)
+		}
+	}
+	if infer {
+		profile.Types = make([]ColumnType, profile.Cols)
+		for i := range profile.Types {
+			if i < len(guesses) {
+				profile.Types[i] = guesses[i].columnType()
+			} else {
+				profile.Types[i] = ColumnType{Type: CellTypeText}
+			}
+		}
+	}
+	if infer && widths {
+		profile.fitTypes(formats)
+	}
+	return profile, nil
+}
+
+// fit takes the widths of the text of a row in.
+func (p *TxtProfile) fit(values []string, header bool, opt *CellOption, formats *numberFormats) {
+	if header {
+		for len(p.HeaderWidths) < len(values) {
+			p.HeaderWidths = append(p.HeaderWidths, 0)
+		}
+		for i, value := range values {
+			p.HeaderWidths[i] = max(p.HeaderWidths[i], textWidth(value))
+		}
+		return
+	}
+	for len(p.Widths) < len(values) {
+		p.Widths = append(p.Widths, 0)
+		p.textWidths = append(p.textWidths, 0)
+		p.floatWidths = append(p.floatWidths, 0)
+	}
+	for i, value := range values {
+		p.textWidths[i] = max(p.textWidths[i], textWidth(value))
+		if len(formats.code(i)) > 0 {
+			p.Widths[i] = p.textWidths[i]
+			continue
+		}
+		p.Widths[i] = max(p.Widths[i], textWidth(shownText(value, opt, false)))
+		p.floatWidths[i] = max(p.floatWidths[i], textWidth(shownText(value, opt, true)))
+	}
+}
+
+// fitTypes measures the data cells of the inferred types as they are shown.
+func (p *TxtProfile) fitTypes(formats *numberFormats) {
+	for i := range p.Widths {
+		if i >= len(p.Types) {
+			break
+		}
+		switch {
+		case len(formats.code(i)) > 0:
+		case p.Types[i].Type == CellTypeDate && p.Widths[i] > 0:
+			p.Widths[i] = textWidth(p.Types[i].NumFmt)
+		case p.Types[i].Type == CellTypeFloat:
+			p.Widths[i] = p.floatWidths[i]
+		default:
+			p.Widths[i] = p.textWidths[i]
+		}
+	}
+}
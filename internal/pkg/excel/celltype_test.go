@@ -0,0 +1,99 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColumnType(t *testing.T) {
+	testcases := []struct {
+		values []string
+		expect ColumnType
+	}{
+		{[]string{"true", "FALSE", ""}, ColumnType{Type: CellTypeBool}},
+		{[]string{"1", "-20", "300"}, ColumnType{Type: CellTypeInt, NumFmt: "0"}},
+		{[]string{"1", "2.5", "1e3"}, ColumnType{Type: CellTypeFloat}},
+		{[]string{"007", "123"}, ColumnType{Type: CellTypeText}},
+		{[]string{"1234567890123456789"}, ColumnType{Type: CellTypeText}},
+		{[]string{"NaN", "Inf"}, ColumnType{Type: CellTypeText}},
+		{[]string{"07/15/2024 14:11:18.978", "07/15/2024 14:11:20.011"},
+			ColumnType{Type: CellTypeDate, Layout: "01/02/2006 15:04:05", NumFmt: "yyyy-mm-dd hh:mm:ss.000"}},
+		{[]string{"2024-01-01", "2024-01-02"},
+			ColumnType{Type: CellTypeDate, Layout: "2006-01-02", NumFmt: "yyyy-mm-dd"}},
+		{[]string{"14:11:18"}, ColumnType{Type: CellTypeDate, Layout: "15:04:05", NumFmt: "hh:mm:ss"}},
+		{[]string{"10/Oct/2000:13:55:36 -0700"},
+			ColumnType{Type: CellTypeDate, Layout: "02/Jan/2006:15:04:05 -0700", NumFmt: "yyyy-mm-dd hh:mm:ss"}},
+		{[]string{"2024-01-01", "abc"}, ColumnType{Type: CellTypeText}},
+		{[]string{"", " "}, ColumnType{Type: CellTypeText}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			guess := newColumnGuess(DefaultDateLayouts, nil)
+			for _, value := range tc.values {
+				guess.add(value)
+			}
+			assert.Equal(t, tc.expect, guess.columnType())
+		})
+	}
+}
+
+func TestColumnTypeValue(t *testing.T) {
+	testcases := []struct {
+		columnType ColumnType
+		value      string
+		expect     interface{}
+		expectOk   bool
+	}{
+		{ColumnType{Type: CellTypeBool}, "TRUE", true, true},
+		{ColumnType{Type: CellTypeInt}, "-42", int64(-42), true},
+		{ColumnType{Type: CellTypeInt}, " ", nil, false},
+		{ColumnType{Type: CellTypeFloat}, "39.596607", 39.6, true},
+		{ColumnType{Type: CellTypeDate, Layout: "01/02/2006 15:04:05"}, "07/15/2024 14:11:18.978",
+			time.Date(2024, 7, 15, 14, 11, 18, 978000000, time.UTC), true},
+		{ColumnType{Type: CellTypeDate, Layout: "15:04:05"}, "12:00:00", 0.5, true},
+		{ColumnType{Type: CellTypeText}, "007", "007", true},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, actualOk := tc.columnType.Value(tc.value, &CellOption{DecimalPlaces: 2, Round: true})
+			assert.Equal(t, tc.expectOk, actualOk)
+			if tc.expectOk {
+				assert.Equal(t, tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestColumnTypeValueDecimals(t *testing.T) {
+	testcases := []struct {
+		columnType ColumnType
+		value      string
+		expect     interface{}
+	}{
+		{ColumnType{Type: CellTypeFloat}, "12", formattedValue{value: 12.0, numFmt: "0.00"}},
+		{ColumnType{Type: CellTypeFloat}, "1.5", formattedValue{value: 1.5, numFmt: "0.00"}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, ok := tc.columnType.Value(tc.value, &CellOption{DecimalPlaces: 2})
+			assert.True(t, ok)
+			assert.Equal(t, tc.expect, actual)
+		})
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ColumnSelector picks input columns by 1-based Index, header Name or header
+// Pattern, exactly one of them. Rename is the header of the picked column, and
+// for a pattern it may refer to submatches like "$1".
+type ColumnSelector struct {
+	Index   int    `yaml:"Index"`
+	Name    string `yaml:"Name"`
+	Pattern string `yaml:"Pattern"`
+	Rename  string `yaml:"Rename"`
+	regexp  *regexp.Regexp
+}
+
+// Columns are the columns written, in the order they are written. A pattern
+// picks every column whose header it matches, in input order.
+type Columns struct {
+	Selectors []ColumnSelector `yaml:"Columns"`
+}
+
+func (s *ColumnSelector) String() string {
+	switch {
+	case s.Index > 0:
+		return strconv.Itoa(s.Index)
+	case len(s.Pattern) > 0:
+		return "/" + s.Pattern + "/"
+	}
+	return s.Name
+}
+
+// ParseColumns reads a list of columns separated by commas, e.g.
+// "1=Time,/Processor Time$/,Memory". An item is a 1-based index, a regular
+// expression between slashes or a header name, optionally followed by "=" and
+// the new header.
+func ParseColumns(text string) (*Columns, error) {
+	columns := &Columns{}
+	rest := text
+	for more := true; more; {
+		sel := ColumnSelector{}
+		var item string
+		if strings.HasPrefix(strings.TrimSpace(rest), "/") {
+			rest = strings.TrimSpace(rest)
+			end := patternEnd(rest)
+			if end < 0 {
+				return nil, ErrInvalidColumns.Details("columns", text, "reason", "regex is not closed")
+			}
+			sel.Pattern = strings.ReplaceAll(rest[1:end], `\/`, "/")
+			item, rest, more = strings.Cut(rest[end+1:], ",")
+			item = strings.TrimSpace(item)
+			if len(item) > 0 && !strings.HasPrefix(item, "=") {
+				return nil, ErrInvalidColumns.Details("columns", text, "reason", "unexpected text after regex")
+			}
+			sel.Rename = strings.TrimSpace(strings.TrimPrefix(item, "="))
+		} else {
+			item, rest, more = strings.Cut(rest, ",")
+			target, rename, _ := strings.Cut(item, "=")
+			target = strings.TrimSpace(target)
+			if n, err := strconv.Atoi(target); err == nil {
+				if n <= 0 {
+					return nil, ErrInvalidColumns.Details("columns", text, "reason", "index must be 1 or more")
+				}
+				sel.Index = n
+			} else {
+				sel.Name = target
+			}
+			sel.Rename = strings.TrimSpace(rename)
+		}
+		columns.Selectors = append(columns.Selectors, sel)
+	}
+	err := columns.validate()
+	if err != nil {
+		return nil, ErrInvalidColumns.Details("columns", text, "reason", err.Error())
+	}
+	return columns, nil
+}
+
+// patternEnd returns the index of the slash closing the pattern at the start
+// of s, or -1. A slash in the pattern is escaped as `\/`.
+func patternEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+		} else if s[i] == '/' {
+			return i
+		}
+	}
+	return -1
+}
+
+// LoadColumns reads the columns from a YAML file.
+func LoadColumns(pathname string) (*Columns, error) {
+	data, err := os.ReadFile(pathname)
+	if err != nil {
+		return nil, ErrLoadColumns.Details("path", pathname).Wrap(err)
+	}
+	columns := &Columns{}
+	err = yaml.UnmarshalStrict(data, columns)
+	if err != nil {
+		return nil, ErrLoadColumns.Details("path", pathname).Wrap(err)
+	}
+	err = columns.validate()
+	if err != nil {
+		return nil, ErrInvalidColumns.Details("path", pathname, "reason", err.Error())
+	}
+	return columns, nil
+}
+
+type columnsError string
+
+func (c columnsError) Error() string {
+	return string(c)
+}
+
+func (c *Columns) validate() error {
+	if len(c.Selectors) <= 0 {
+		return columnsError("no columns")
+	}
+	for i := range c.Selectors {
+		sel := &c.Selectors[i]
+		picks := 0
+		for _, set := range []bool{sel.Index != 0, len(sel.Name) > 0, len(sel.Pattern) > 0} {
+			if set {
+				picks++
+			}
+		}
+		switch {
+		case picks != 1:
+			return columnsError("column " + strconv.Itoa(i+1) + " must have one of index, name, or pattern")
+		case sel.Index < 0:
+			return columnsError("index must be 1 or more")
+		case len(sel.Pattern) > 0:
+			re, err := regexp.Compile(sel.Pattern)
+			if err != nil {
+				return columnsError(err.Error())
+			}
+			sel.regexp = re
+		}
+	}
+	return nil
+}
+
+// ByHeader tells whether the columns need the header row, to be found by name
+// or pattern or to be renamed.
+func (c *Columns) ByHeader() bool {
+	for _, sel := range c.Selectors {
+		if len(sel.Name) > 0 || len(sel.Pattern) > 0 || len(sel.Rename) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// columnError is a column that is not in an input. Like readError it is
+// wrapped by wrapReadError.
+type columnError struct {
+	column string
+}
+
+func (c *columnError) Error() string {
+	return "column not found: " + c.column
+}
+
+// projection is Columns resolved for one pass over an input. The first row
+// read resolves it, and is the header when header is set.
+type projection struct {
+	columns *Columns
+	header  bool
+	sources []int
+	names   []string
+}
+
+func newProjection(opt *CellOption) *projection {
+	if opt == nil || opt.Columns == nil {
+		return nil
+	}
+	return &projection{columns: opt.Columns, header: opt.Header}
+}
+
+// project returns the values of the picked columns in their order. The header
+// row gets the new headers, and a short row empty values.
+func (p *projection) project(values []string) ([]string, error) {
+	if p == nil {
+		return values, nil
+	}
+	if p.sources == nil {
+		var header []string
+		if p.header {
+			header = values
+		}
+		err := p.resolve(header)
+		if err != nil {
+			return nil, err
+		}
+		if p.header {
+			return slices.Clone(p.names), nil
+		}
+	}
+	projected := make([]string, len(p.sources))
+	for i, src := range p.sources {
+		if src < len(values) {
+			projected[i] = values[src]
+		}
+	}
+	return projected, nil
+}
+
+func (p *projection) resolve(header []string) error {
+	p.sources = []int{}
+	p.names = []string{}
+	for _, sel := range p.columns.Selectors {
+		switch {
+		case sel.Index > 0:
+			if header != nil && sel.Index > len(header) {
+				return &columnError{column: sel.String()}
+			}
+			p.add(sel.Index-1, header, sel.Rename)
+		case sel.regexp != nil:
+			found := false
+			for src, name := range header {
+				match := sel.regexp.FindStringSubmatchIndex(name)
+				if match == nil {
+					continue
+				}
+				rename := ""
+				if len(sel.Rename) > 0 {
+					rename = string(sel.regexp.ExpandString(nil, sel.Rename, name, match))
+				}
+				p.add(src, header, rename)
+				found = true
+			}
+			if !found {
+				return &columnError{column: sel.String()}
+			}
+		default:
+			src := slices.Index(header, sel.Name)
+			if src < 0 {
+				return &columnError{column: sel.String()}
+			}
+			p.add(src, header, sel.Rename)
+		}
+	}
+	return nil
+}
+
+func (p *projection) add(src int, header []string, rename string) {
+	name := rename
+	if len(name) <= 0 && src < len(header) {
+		name = header[src]
+	}
+	p.sources = append(p.sources, src)
+	p.names = append(p.names, name)
+}
+
+// inputColumn returns the 1-based input column of a written column.
+func (p *projection) inputColumn(col int) int {
+	if p == nil || col <= 0 || col > len(p.sources) {
+		return col
+	}
+	return p.sources[col-1] + 1
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kenita8/xlcmd/internal/pkg/excel/excelize"
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+	"github.com/kenita8/xlcmd/internal/pkg/file/csv"
+
+	"github.com/stretchr/testify/assert"
+	rawExcelize "github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+func TestParseColumns(t *testing.T) {
+	testcases := []struct {
+		text      string
+		expect    []ColumnSelector
+		expectErr error
+	}{
+		{"1", []ColumnSelector{{Index: 1}}, nil},
+		{"1=Time, Memory ,Disk=Disk %", []ColumnSelector{{Index: 1, Rename: "Time"}, {Name: "Memory"}, {Name: "Disk", Rename: "Disk %"}}, nil},
+		{`/\\(.*)\\% Processor Time$/=$1 CPU,/a\/b{1,2}/`, []ColumnSelector{{Pattern: `\\(.*)\\% Processor Time$`, Rename: "$1 CPU"}, {Pattern: "a/b{1,2}"}}, nil},
+		{"0", nil, ErrInvalidColumns},
+		{"/abc", nil, ErrInvalidColumns},
+		{"/abc/x", nil, ErrInvalidColumns},
+		{"/(/", nil, ErrInvalidColumns},
+		{"a,,b", nil, ErrInvalidColumns},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			columns, err := ParseColumns(tc.text)
+			if tc.expectErr != nil {
+				assert.True(t, errors.Is(err, tc.expectErr))
+				return
+			}
+			assert.Nil(t, err)
+			for i := range columns.Selectors {
+				columns.Selectors[i].regexp = nil
+			}
+			assert.Equal(t, tc.expect, columns.Selectors)
+		})
+	}
+}
+
+func TestPasteTxtFileColumns(t *testing.T) {
+	input := writeTestFile(t, "input.csv", "Time,\\\\host\\cpu,\\\\host\\mem,\\\\host\\disk\n"+
+		"2024-07-01 10:00:00,1.5,100,7\n"+
+		"2024-07-01 10:00:01,2.5,200\n")
+	testcases := []struct {
+		columns    string
+		header     bool
+		expectRows [][]string
+		expectErr  error
+	}{
+		{
+			columns:    "1=When,/host\\\\(cpu|disk)$/=$1",
+			header:     true,
+			expectRows: [][]string{{"When", "cpu", "disk"}, {"2024-07-01 10:00:00", "1.5", "7"}, {"2024-07-01 10:00:01", "2.5"}},
+		},
+		{
+			columns:    "\\\\host\\mem,Time",
+			header:     true,
+			expectRows: [][]string{{"\\\\host\\mem", "Time"}, {"100", "2024-07-01 10:00:00"}, {"200", "2024-07-01 10:00:01"}},
+		},
+		{
+			columns:    "3,1",
+			expectRows: [][]string{{"\\\\host\\mem", "Time"}, {"100", "2024-07-01 10:00:00"}, {"200", "2024-07-01 10:00:01"}},
+		},
+		{
+			columns:   "Time,Swap",
+			header:    true,
+			expectErr: ErrColumnNotFound,
+		},
+		{
+			columns:   "5",
+			header:    true,
+			expectErr: ErrColumnNotFound,
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			columns, err := ParseColumns(tc.columns)
+			assert.Nil(t, err)
+			opt := &CellOption{DecimalPlaces: -1, Header: tc.header, OnError: OnErrorSkip, Columns: columns}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			input := csv.NewCsvFile(input, "UTF-8")
+			input.FieldsPerRecord = -1
+			err = e.PasteTxtFile(input, "data", opt, &SheetOption{AutoFilter: true})
+			if tc.expectErr != nil {
+				assert.True(t, errors.Is(err, tc.expectErr))
+				e.Close()
+				return
+			}
+			assert.Nil(t, err)
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			rows, err := f.GetRows("data")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectRows, rows)
+		})
+	}
+}
+
+func TestProfileTxtFileColumnsRejectedHeader(t *testing.T) {
+	long := strings.Repeat("x", rawExcelize.TotalCellChars+1)
+	columns, err := ParseColumns("Value")
+	assert.Nil(t, err)
+	testcases := []struct {
+		data   string
+		expect *TxtProfile
+	}{
+		{"Name,Value\nx,1\ny,2\n", &TxtProfile{Rows: 3, Cols: 1, Types: []ColumnType{{Type: CellTypeInt, NumFmt: "0"}}}},
+		// the header is the first record that is written
+		{"a\"b,c\nName,Value\nx,1\ny,2\n", &TxtProfile{Rows: 3, Cols: 1, Types: []ColumnType{{Type: CellTypeInt, NumFmt: "0"}}}},
+		{long + ",c\nName,Value\nx,1\ny,2\n", &TxtProfile{Rows: 3, Cols: 1, Types: []ColumnType{{Type: CellTypeInt, NumFmt: "0"}}}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			input := writeTestFile(t, "input.csv", tc.data)
+			opt := &CellOption{Header: true, InferTypes: true, OnError: OnErrorSkip, Columns: columns}
+			actual, err := ProfileTxtFile(csv.NewCsvFile(input, "UTF-8"), opt)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expect, actual)
+		})
+	}
+}
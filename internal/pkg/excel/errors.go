@@ -13,16 +13,34 @@
 // limitations under the License.
 package excel
 
-import "github.com/kenita8/errors"
+import "github.com/kenita8/xlcmd/internal/pkg/errors"
 
 var (
-	ErrNotOpened       = errors.New("XLSX file has not been opened yet")
-	ErrOpenXlsxFile    = errors.New("unable to open XLSX file")
-	ErrSaveAsFile      = errors.New("unable to save output file")
-	ErrReadInputFile   = errors.New("unable to read from input file")
-	ErrNewSheet        = errors.New("failed to create new sheet")
-	ErrConvertCellName = errors.New("unable to convert cell name")
-	ErrGetCellValue    = errors.New("unable to get cell value")
-	ErrAddChart        = errors.New("failed to add chart")
-	ErrSetCellValue    = errors.New("unable to write data to cell")
+	ErrNotOpened           = errors.New("XLSX file has not been opened yet")
+	ErrOpenXlsxFile        = errors.New("unable to open XLSX file")
+	ErrOpenTemplate        = errors.New("unable to open template workbook")
+	ErrSaveAsFile          = errors.New("unable to save output file")
+	ErrReadInputFile       = errors.New("unable to read from input file")
+	ErrNewSheet            = errors.New("failed to create new sheet")
+	ErrConvertCellName     = errors.New("unable to convert cell name")
+	ErrGetCellValue        = errors.New("unable to get cell value")
+	ErrAddChart            = errors.New("failed to add chart")
+	ErrSetCellValue        = errors.New("unable to write data to cell")
+	ErrNewStreamWriter     = errors.New("failed to create stream writer")
+	ErrFlushSheet          = errors.New("unable to flush sheet data")
+	ErrNewStyle            = errors.New("failed to create cell style")
+	ErrSetPanes            = errors.New("unable to freeze panes")
+	ErrAutoFilter          = errors.New("unable to set auto filter")
+	ErrAddTable            = errors.New("failed to add table")
+	ErrDeleteTable         = errors.New("failed to delete table")
+	ErrSetColWidth         = errors.New("unable to set column width")
+	ErrReadSheet           = errors.New("unable to read sheet")
+	ErrPasteTxtFile        = errors.New("failed to paste input file")
+	ErrRejectedRow         = errors.New("row cannot be written")
+	ErrInvalidColumns      = errors.New("columns are invalid")
+	ErrLoadColumns         = errors.New("unable to load column file")
+	ErrColumnNotFound      = errors.New("column not found in input")
+	ErrInvalidWhere        = errors.New("where condition is invalid")
+	ErrInvalidNumberFormat = errors.New("number format is invalid")
+	ErrInvalidMaxWidth     = errors.New("max-width must be more than 0 and at most 255")
 )
@@ -15,13 +15,13 @@ package excel
 
 import (
 	"fmt"
-	"io"
 	"io/fs"
 	"strconv"
+	"strings"
 
 	"github.com/kenita8/xlcmd/internal/pkg/excel/excelize"
 	"github.com/kenita8/xlcmd/internal/pkg/file"
-	"github.com/kenita8/xlcmd/internal/pkg/file/txt"
+	rawExcelize "github.com/xuri/excelize/v2"
 	"go.uber.org/zap"
 )
 
@@ -32,9 +32,111 @@ type Filer interface {
 }
 
 type CellOption struct {
+	// DecimalPlaces is the number of decimals shown for numbers with a
+	// fraction, which are stored as they are. Negative shows them as stored.
 	DecimalPlaces int
+	// Round stores numbers rounded to DecimalPlaces instead, shown as they
+	// are stored.
+	Round       bool
+	InferTypes  bool
+	DateLayouts []string
+	Header      bool
+	// Number reads numbers with these conventions, including currency and
+	// percent, instead of as Go floats.
+	Number *NumberLocale
+	// OnError decides what happens to a bad row. Zero is OnErrorFail.
+	OnError ErrorPolicy
+	// Columns, if not nil, are the only columns written.
+	Columns *Columns
+	// Where, if not nil, leaves out the rows that do not meet it. It reads
+	// the columns of the input, before Columns picks them.
+	Where *Where
+	// NumberFormats are the number formats of the written columns, over
+	// DecimalPlaces and the formats of inferred types.
+	NumberFormats []NumberFormat
 }
 
+// ErrorPolicy decides what happens to a row that cannot be read, such as a
+// malformed CSV record, or that has a cell Excel cannot hold.
+type ErrorPolicy string
+
+const (
+	// OnErrorFail stops at the first bad row.
+	OnErrorFail ErrorPolicy = "fail"
+	// OnErrorSkip leaves bad rows out.
+	OnErrorSkip ErrorPolicy = "skip"
+	// OnErrorReport leaves bad rows out like OnErrorSkip, for the caller to
+	// report them from Rejected.
+	OnErrorReport ErrorPolicy = "report"
+)
+
+// PasteMode decides what happens when the target sheet already exists.
+type PasteMode string
+
+const (
+	// PasteModeReplace clears the sheet and writes the input from the start
+	// cell. Written in place, the input replaces only the cells it covers.
+	PasteModeReplace PasteMode = "replace"
+	// PasteModeAppend writes the input below the last used row, from the
+	// column of the start cell.
+	PasteModeAppend PasteMode = "append"
+	// PasteModeSkip leaves the sheet untouched.
+	PasteModeSkip PasteMode = "skip"
+)
+
+// SheetOption formats a sheet created from an input. The row of the start cell
+// is the header row. Appending to an existing sheet keeps the formatting the
+// sheet already has.
+type SheetOption struct {
+	HeaderStyle  bool
+	FreezeHeader bool
+	AutoFilter   bool
+	TableStyle   string
+	Mode         PasteMode
+	SkipHeader   bool
+	// Split decides where an input goes on past the limits of a sheet. Zero
+	// is SplitSheets.
+	Split SplitPolicy
+	// StartCell is the top left cell of the input, e.g. "B5". Zero is A1.
+	// The header style, the table and the auto filter cover the cells of the
+	// input only. An existing sheet is written in place, keeping the cells
+	// above and left of the input, e.g. a title block. Replacing it clears
+	// the cells from the start cell down and right.
+	StartCell string
+	// InPlace writes the cells into the sheet instead of through a stream
+	// writer. It must be set for a sheet that more than one input is written
+	// to, since a streamed sheet cannot be written again before it is saved.
+	// An input written to a sheet already written since the workbook was
+	// opened is a block of the sheet, and does not clear it.
+	InPlace bool
+	// AutoFit sets the width of the columns of the input to fit their text,
+	// up to MaxWidth characters. Zero MaxWidth is DefaultMaxWidth.
+	AutoFit  bool
+	MaxWidth float64
+}
+
+// rowWriter writes the rows of a sheet in ascending order.
+type rowWriter interface {
+	SetRow(cell string, values []interface{}, opts ...rawExcelize.RowOpts) error
+	SetColWidth(minVal int, maxVal int, width float64) error
+	AddTable(table *rawExcelize.Table) error
+	Flush() error
+}
+
+// cellWriter writes rows into the sheet in place, keeping the rows there.
+type cellWriter struct {
+	xlFile excelize.ExcelizeFiler
+	sheet  string
+}
+
+const (
+	headerStyleKey = "header"
+	// filterDatabase is the defined name holding the auto filter of a sheet,
+	// which excelize writes as filterCriteria.
+	filterDatabase = "_xlnm._FilterDatabase"
+	filterCriteria = "_xlnm.Criteria"
+)
+
 var (
 	filer     Filer              = &file.File{}
 	excelizer excelize.Excelizer = &excelize.Excelize{}
@@ -45,6 +147,22 @@ type Excel struct {
 	log      *zap.Logger
 	pathname string
 	new      bool
+	// template tells that a new workbook started as a copy of a template,
+	// whose sheets are all kept.
+	template bool
+	styles   map[string]int
+	rejected []RejectedRow
+	// spills are the workbooks after this one that inputs go on to.
+	spills []*Excel
+	// written are the sheets written since the workbook was opened, in lower
+	// case.
+	written map[string]bool
+	// targets are the sheets inputs are written to, in lower case, which
+	// continuation sheets of other inputs do not take.
+	targets map[string]bool
+	// widths are the column widths set to fit the blocks of a sheet so far,
+	// by sheet in lower case and column.
+	widths map[string]map[int]float64
 }
 
 func NewExcel(log *zap.Logger) *Excel {
@@ -82,6 +200,33 @@ func (e *Excel) Open(pathname string) error {
 	return err
 }
 
+// OpenWithTemplate is Open, except that a workbook that does not exist yet
+// starts as a copy of the template, with its styles, images, sheets and
+// formulas. The template is left as it is. An empty template is Open.
+func (e *Excel) OpenWithTemplate(pathname string, template string) error {
+	_, err := filer.Stat(pathname)
+	if err == nil || len(template) <= 0 {
+		return e.Open(pathname)
+	}
+	e.pathname = pathname
+	e.log.Info("new file from template", zap.String("path", e.pathname), zap.String("template", template))
+	xlFile, err := excelizer.OpenFile(template)
+	if err != nil {
+		return ErrOpenTemplate.Details("template", template).Wrap(err)
+	}
+	// The formulas of the template are calculated again when the workbook
+	// is opened, so that they take in the data written to it.
+	err = xlFile.UpdateLinkedValue()
+	if err != nil {
+		xlFile.Close()
+		return ErrOpenTemplate.Details("template", template).Wrap(err)
+	}
+	e.xlFile = xlFile
+	e.new = true
+	e.template = true
+	return nil
+}
+
 func (e *Excel) NewSheet(name string) error {
 	if e.xlFile == nil {
 		return ErrNotOpened
@@ -93,6 +238,84 @@ func (e *Excel) NewSheet(name string) error {
 	return nil
 }
 
+// formattedValue is a value with the number format it is shown with.
+type formattedValue struct {
+	value  interface{}
+	numFmt string
+}
+
+// cellValue converts a number, which is shown with opt.DecimalPlaces when it
+// has a fraction. Other text is returned as it is.
+func cellValue(value string, opt *CellOption) interface{} {
+	if opt != nil && opt.Number != nil {
+		return localeCellValue(value, opt)
+	}
+	valuef, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	if opt != nil && opt.Round {
+		valuef = roundValue(valuef, opt.DecimalPlaces)
+	}
+	numFmt := decimalsNumFmt(opt)
+	if len(numFmt) > 0 && !intPattern.MatchString(strings.TrimSpace(value)) {
+		return formattedValue{value: valuef, numFmt: numFmt}
+	}
+	return valuef
+}
+
+func roundValue(valuef float64, decimalPlaces int) float64 {
+	valuef, _ = strconv.ParseFloat(strconv.FormatFloat(valuef, 'f', decimalPlaces, 64), 64)
+	return valuef
+}
+
+// localeCellValue converts a number written with opt.Number.
+func localeCellValue(value string, opt *CellOption) interface{} {
+	n, ok := parseLocaleNumber(value, opt.Number)
+	if !ok {
+		return value
+	}
+	valuef, err := strconv.ParseFloat(n.text, 64)
+	if err != nil {
+		return value
+	}
+	if opt.Round {
+		valuef = roundValue(valuef, opt.DecimalPlaces)
+	}
+	if opt.DecimalPlaces >= 0 {
+		n.decimals = min(n.decimals, opt.DecimalPlaces)
+	}
+	if n.percent {
+		valuef, _ = strconv.ParseFloat(strconv.FormatFloat(valuef, 'f', -1, 64)+"e-2", 64)
+	}
+	numFmt := n.numFmt()
+	if len(numFmt) <= 0 && len(decimalsNumFmt(opt)) > 0 && n.decimals > 0 {
+		numFmt = "0." + strings.Repeat("0", n.decimals)
+	}
+	if len(numFmt) <= 0 {
+		return valuef
+	}
+	return formattedValue{value: valuef, numFmt: numFmt}
+}
+
+func (e *Excel) numFmtStyle(numFmt string) (int, error) {
+	if len(numFmt) <= 0 {
+		return 0, nil
+	}
+	if e.styles == nil {
+		e.styles = map[string]int{}
+	}
+	if style, ok := e.styles[numFmt]; ok {
+		return style, nil
+	}
+	style, err := e.xlFile.NewStyle(&rawExcelize.Style{CustomNumFmt: &numFmt})
+	if err != nil {
+		return 0, ErrNewStyle.Details("format", numFmt).Wrap(err)
+	}
+	e.styles[numFmt] = style
+	return style, nil
+}
+
 func (e *Excel) SetCellValue(value string, sheet string, col int, row int, opt *CellOption) error {
 	if e.xlFile == nil {
 		return ErrNotOpened
@@ -101,19 +324,25 @@ func (e *Excel) SetCellValue(value string, sheet string, col int, row int, opt *
 	if err != nil {
 		return ErrConvertCellName.Details("col", col, "row", row).Wrap(err)
 	}
-	valuef, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		err = e.xlFile.SetCellValue(sheet, cell, value)
-	} else {
-		if opt != nil {
-			err = e.xlFile.SetCellFloat(sheet, cell, valuef, opt.DecimalPlaces, 64)
-		} else {
-			err = e.xlFile.SetCellFloat(sheet, cell, valuef, -1, 64)
-		}
+	typed := cellValue(value, opt)
+	formatted, ok := typed.(formattedValue)
+	if ok {
+		typed = formatted.value
 	}
+	err = e.xlFile.SetCellValue(sheet, cell, typed)
 	if err != nil {
 		return ErrSetCellValue.Details("sheet", sheet, "cell", cell, "data", value).Wrap(err)
 	}
+	if ok {
+		style, err := e.numFmtStyle(formatted.numFmt)
+		if err != nil {
+			return err
+		}
+		err = e.xlFile.SetCellStyle(sheet, cell, cell, style)
+		if err != nil {
+			return ErrSetCellValue.Details("sheet", sheet, "cell", cell, "data", value).Wrap(err)
+		}
+	}
 	e.log.Info("replace", zap.String("sheet", sheet), zap.String("cell", cell), zap.String("text", value))
 	return nil
 }
@@ -134,36 +363,394 @@ func (e *Excel) GetCellValue(sheet string, col int, row int) (string, error) {
 	return value, nil
 }
 
-func (e *Excel) PasteTxtFile(txt txt.TxtFiler, sheet string, opt *CellOption) error {
+func (e *Excel) columnStyles(types []ColumnType) ([]int, error) {
+	styles := make([]int, len(types))
+	for i, t := range types {
+		style, err := e.numFmtStyle(t.NumFmt)
+		if err != nil {
+			return nil, err
+		}
+		styles[i] = style
+	}
+	return styles, nil
+}
+
+// typedCellValue converts a data cell. A value converted for a column with a
+// number format comes as a rawExcelize.Cell, which cellStyles gives the style.
+func typedCellValue(value string, col int, types []ColumnType, opt *CellOption) interface{} {
+	if len(value) <= 0 {
+		return nil
+	}
+	if col >= len(types) {
+		return value
+	}
+	typed, ok := types[col].Value(value, opt)
+	if !ok {
+		return value
+	}
+	if len(types[col].NumFmt) > 0 {
+		return rawExcelize.Cell{Value: typed}
+	}
+	return typed
+}
+
+// cellStyles returns a copy of cells with the styles of their columns.
+func (e *Excel) cellStyles(cells []interface{}, styles []int) ([]interface{}, error) {
+	styled := make([]interface{}, len(cells))
+	for col, cell := range cells {
+		switch c := cell.(type) {
+		case rawExcelize.Cell:
+			if col < len(styles) {
+				c.StyleID = styles[col]
+			}
+			styled[col] = c
+		case formattedValue:
+			style, err := e.numFmtStyle(c.numFmt)
+			if err != nil {
+				return nil, err
+			}
+			styled[col] = rawExcelize.Cell{StyleID: style, Value: c.value}
+		default:
+			styled[col] = cell
+		}
+	}
+	return styled, nil
+}
+
+func (e *Excel) headerStyle() (int, error) {
+	if style, ok := e.styles[headerStyleKey]; ok {
+		return style, nil
+	}
+	style, err := e.xlFile.NewStyle(&rawExcelize.Style{
+		Font:   &rawExcelize.Font{Bold: true},
+		Fill:   rawExcelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9D9D9"}},
+		Border: []rawExcelize.Border{{Type: "bottom", Color: "000000", Style: 1}},
+	})
+	if err != nil {
+		return 0, ErrNewStyle.Details("style", headerStyleKey).Wrap(err)
+	}
+	if e.styles == nil {
+		e.styles = map[string]int{}
+	}
+	e.styles[headerStyleKey] = style
+	return style, nil
+}
+
+// uniqueHeaders makes header names usable as table column names, which must
+// be non-empty and unique.
+func uniqueHeaders(values []string) []string {
+	headers := make([]string, len(values))
+	seen := map[string]bool{}
+	for i, value := range values {
+		name := value
+		if len(strings.TrimSpace(name)) <= 0 {
+			name = "Column" + strconv.Itoa(i+1)
+		}
+		unique := name
+		for n := 2; seen[strings.ToLower(unique)]; n++ {
+			unique = name + " " + strconv.Itoa(n)
+		}
+		seen[strings.ToLower(unique)] = true
+		headers[i] = unique
+	}
+	return headers
+}
+
+func (e *Excel) FreezeHeader(sheet string, rows int) error {
+	if e.xlFile == nil {
+		return ErrNotOpened
+	}
+	topLeft, err := excelizer.CoordinatesToCellName(1, rows+1)
+	if err != nil {
+		return ErrConvertCellName.Details("col", 1, "row", rows+1).Wrap(err)
+	}
+	err = e.xlFile.SetPanes(sheet, &rawExcelize.Panes{
+		Freeze:      true,
+		YSplit:      rows,
+		TopLeftCell: topLeft,
+		ActivePane:  "bottomLeft",
+	})
+	if err != nil {
+		return ErrSetPanes.Details("sheet", sheet).Wrap(err)
+	}
+	return nil
+}
+
+func (e *Excel) AutoFilter(sheet string, topCol int, topRow int, bottomCol int, bottomRow int) error {
 	if e.xlFile == nil {
 		return ErrNotOpened
 	}
+	rangeRef, err := cellRange(topCol, topRow, bottomCol, bottomRow)
+	if err != nil {
+		return err
+	}
+	err = e.xlFile.AutoFilter(sheet, rangeRef, nil)
+	if err != nil {
+		return ErrAutoFilter.Details("sheet", sheet, "range", rangeRef).Wrap(err)
+	}
+	return nil
+}
+
+func cellRange(topCol int, topRow int, bottomCol int, bottomRow int) (string, error) {
+	top, err := excelizer.CoordinatesToCellName(topCol, topRow)
+	if err != nil {
+		return "", ErrConvertCellName.Details("col", topCol, "row", topRow).Wrap(err)
+	}
+	bottom, err := excelizer.CoordinatesToCellName(bottomCol, bottomRow)
+	if err != nil {
+		return "", ErrConvertCellName.Details("col", bottomCol, "row", bottomRow).Wrap(err)
+	}
+	return top + ":" + bottom, nil
+}
+
+// prepareSheet creates the sheet and sets the freeze panes and the auto filter
+// of an input at left and top.
+func (e *Excel) prepareSheet(sheet string, left int, top int, profile *TxtProfile, sheetOpt *SheetOption) error {
 	_, err := e.xlFile.NewSheet(sheet)
+	if err != nil {
+		return ErrNewSheet.Details("sheet", sheet).Wrap(err)
+	}
+	if sheetOpt == nil || profile == nil || profile.Rows <= 0 {
+		return nil
+	}
+	if sheetOpt.FreezeHeader {
+		err = e.FreezeHeader(sheet, top)
+		if err != nil {
+			return err
+		}
+	}
+	// A table comes with its own filter, and Excel rejects overlapping ones.
+	if sheetOpt.AutoFilter && len(sheetOpt.TableStyle) <= 0 {
+		err = e.AutoFilter(sheet, left, top, left+max(profile.Cols, 1)-1, top+profile.Rows-1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Excel) addTable(sw rowWriter, sheet string, left int, top int, cols int, bottom int, style string) error {
+	rangeRef, err := cellRange(left, top, left+max(cols, 1)-1, bottom)
 	if err != nil {
 		return err
 	}
-	err = txt.OpenReadMode()
+	err = sw.AddTable(&rawExcelize.Table{Range: rangeRef, StyleName: style})
+	if err != nil {
+		return ErrAddTable.Details("sheet", sheet, "range", rangeRef).Wrap(err)
+	}
+	return nil
+}
+
+func (w *cellWriter) SetRow(cell string, values []interface{}, opts ...rawExcelize.RowOpts) error {
+	col, row, err := excelizer.CellNameToCoordinates(cell)
 	if err != nil {
 		return err
 	}
-	defer txt.Close()
-	row := 1
-	for {
-		values, err := txt.ReadOneLine()
-		if err == io.EOF {
-			break
+	rowStyle := 0
+	for _, opt := range opts {
+		rowStyle = opt.StyleID
+	}
+	for i, value := range values {
+		style := rowStyle
+		if c, ok := value.(rawExcelize.Cell); ok {
+			value = c.Value
+			style = c.StyleID
 		}
+		name, err := excelizer.CoordinatesToCellName(col+i, row)
 		if err != nil {
-			return ErrReadInputFile.Details("file", txt.Filename()).Wrap(err)
+			return err
+		}
+		if value != nil {
+			err = w.xlFile.SetCellValue(w.sheet, name, value)
+			if err != nil {
+				return err
+			}
 		}
-		col := 1
-		for _, value := range values {
-			e.SetCellValue(value, sheet, col, row, opt)
-			col++
+		if style != 0 {
+			err = w.xlFile.SetCellStyle(w.sheet, name, name, style)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *cellWriter) SetColWidth(minVal int, maxVal int, width float64) error {
+	start, err := rawExcelize.ColumnNumberToName(minVal)
+	if err != nil {
+		return err
+	}
+	end, err := rawExcelize.ColumnNumberToName(maxVal)
+	if err != nil {
+		return err
+	}
+	return w.xlFile.SetColWidth(w.sheet, start, end, width)
+}
+
+func (w *cellWriter) AddTable(table *rawExcelize.Table) error {
+	return w.xlFile.AddTable(w.sheet, table)
+}
+
+func (w *cellWriter) Flush() error {
+	return nil
+}
+
+func (e *Excel) hasSheet(sheet string) bool {
+	for _, name := range e.xlFile.GetSheetList() {
+		if strings.EqualFold(name, sheet) {
+			return true
+		}
+	}
+	return false
+}
+
+// LastRow returns the number of the last row holding a value, or 0 when the
+// sheet is empty.
+func (e *Excel) LastRow(sheet string) (int, error) {
+	if e.xlFile == nil {
+		return 0, ErrNotOpened
+	}
+	rows, err := e.xlFile.Rows(sheet)
+	if err != nil {
+		return 0, ErrReadSheet.Details("sheet", sheet).Wrap(err)
+	}
+	defer rows.Close()
+	last := 0
+	for row := 1; rows.Next(); row++ {
+		columns, err := rows.Columns()
+		if err != nil {
+			return 0, ErrReadSheet.Details("sheet", sheet, "row", row).Wrap(err)
+		}
+		for _, value := range columns {
+			if len(value) > 0 {
+				last = row
+				break
+			}
+		}
+	}
+	if rows.Error() != nil {
+		return 0, ErrReadSheet.Details("sheet", sheet).Wrap(rows.Error())
+	}
+	return last, nil
+}
+
+// clearTables deletes the tables of the sheet, so that a replaced sheet does
+// not keep tables covering the old data.
+func (e *Excel) clearTables(sheet string) error {
+	tables, err := e.xlFile.GetTables(sheet)
+	if err != nil {
+		return ErrDeleteTable.Details("sheet", sheet).Wrap(err)
+	}
+	for _, table := range tables {
+		err = e.xlFile.DeleteTable(table.Name)
+		if err != nil {
+			return ErrDeleteTable.Details("sheet", sheet, "table", table.Name).Wrap(err)
+		}
+	}
+	return nil
+}
+
+// extendRanges makes the tables and auto filter ending at row last end at
+// row bottom.
+func (e *Excel) extendRanges(sheet string, last int, bottom int) error {
+	tables, err := e.xlFile.GetTables(sheet)
+	if err != nil {
+		return ErrAddTable.Details("sheet", sheet).Wrap(err)
+	}
+	for _, table := range tables {
+		rangeRef, ok := extendRange(table.Range, last, bottom)
+		if !ok {
+			continue
+		}
+		err = e.xlFile.DeleteTable(table.Name)
+		if err != nil {
+			return ErrDeleteTable.Details("sheet", sheet, "table", table.Name).Wrap(err)
+		}
+		table.Range = rangeRef
+		err = e.xlFile.AddTable(sheet, &table)
+		if err != nil {
+			return ErrAddTable.Details("sheet", sheet, "range", rangeRef).Wrap(err)
+		}
+	}
+	for _, name := range e.xlFile.GetDefinedName() {
+		if (name.Name != filterDatabase && name.Name != filterCriteria) || !strings.EqualFold(name.Scope, sheet) {
+			continue
+		}
+		refersTo := name.RefersTo[strings.LastIndex(name.RefersTo, "!")+1:]
+		rangeRef, ok := extendRange(strings.ReplaceAll(refersTo, "$", ""), last, bottom)
+		if !ok {
+			continue
+		}
+		err = e.xlFile.AutoFilter(sheet, rangeRef, nil)
+		if err != nil {
+			return ErrAutoFilter.Details("sheet", sheet, "range", rangeRef).Wrap(err)
+		}
+	}
+	return nil
+}
+
+// extendRange returns rangeRef with its bottom row moved from last to bottom,
+// or false when it does not end at row last.
+func extendRange(rangeRef string, last int, bottom int) (string, bool) {
+	top, end, found := strings.Cut(rangeRef, ":")
+	if !found {
+		return "", false
+	}
+	topCol, topRow, err := excelizer.CellNameToCoordinates(top)
+	if err != nil {
+		return "", false
+	}
+	bottomCol, bottomRow, err := excelizer.CellNameToCoordinates(end)
+	if err != nil || bottomRow != last || topRow > last {
+		return "", false
+	}
+	extended, err := cellRange(topCol, topRow, bottomCol, bottom)
+	if err != nil {
+		return "", false
+	}
+	return extended, true
+}
+
+// clearCells clears the values of the sheet from left and top down and right.
+func (e *Excel) clearCells(sheet string, left int, top int) error {
+	rows, err := e.xlFile.Rows(sheet)
+	if err != nil {
+		return ErrReadSheet.Details("sheet", sheet).Wrap(err)
+	}
+	cells := []string{}
+	for row := 1; rows.Next(); row++ {
+		columns, err := rows.Columns()
+		if err != nil {
+			rows.Close()
+			return ErrReadSheet.Details("sheet", sheet, "row", row).Wrap(err)
+		}
+		if row < top {
+			continue
+		}
+		for col := left; col <= len(columns); col++ {
+			if len(columns[col-1]) <= 0 {
+				continue
+			}
+			cell, err := excelizer.CoordinatesToCellName(col, row)
+			if err != nil {
+				rows.Close()
+				return ErrConvertCellName.Details("col", col, "row", row).Wrap(err)
+			}
+			cells = append(cells, cell)
+		}
+	}
+	err = rows.Error()
+	rows.Close()
+	if err != nil {
+		return ErrReadSheet.Details("sheet", sheet).Wrap(err)
+	}
+	for _, cell := range cells {
+		err = e.xlFile.SetCellValue(sheet, cell, nil)
+		if err != nil {
+			return ErrSetCellValue.Details("sheet", sheet, "cell", cell).Wrap(err)
 		}
-		row += 1
 	}
-	e.log.Info("add sheet", zap.String("sheet", sheet), zap.String("src", txt.Filename()))
 	return nil
 }
 
@@ -225,15 +812,11 @@ func (e *Excel) CellNameToCoordinates(cell string) (int, int, error) {
 }
 
 func (e *Excel) RangeString(sheet string, topCol int, topRow int, bottomCol int, bottomRow int) (string, error) {
-	top, err := excelizer.CoordinatesToCellName(topCol, topRow)
-	if err != nil {
-		return "", ErrConvertCellName.Details("col", topCol, "row", topRow).Wrap(err)
-	}
-	bottom, err := excelizer.CoordinatesToCellName(bottomCol, bottomRow)
+	rangeRef, err := cellRange(topCol, topRow, bottomCol, bottomRow)
 	if err != nil {
-		return "", ErrConvertCellName.Details("col", bottomCol, "row", bottomRow).Wrap(err)
+		return "", err
 	}
-	return fmt.Sprintf("'%s'!%s:%s", sheet, top, bottom), nil
+	return fmt.Sprintf("'%s'!%s", sheet, rangeRef), nil
 }
 
 func (e *Excel) GetSheetList() []string {
@@ -246,7 +829,11 @@ func (e *Excel) Save() error {
 	}
 	var err error
 	if e.new {
-		e.xlFile.DeleteSheet("Sheet1")
+		// The empty sheet of a new workbook goes, but not a Sheet1 of a
+		// template.
+		if !e.template {
+			e.xlFile.DeleteSheet("Sheet1")
+		}
 		err = e.xlFile.SaveAs(e.pathname)
 	} else {
 		err = e.xlFile.Save()
@@ -255,6 +842,12 @@ func (e *Excel) Save() error {
 		return ErrSaveAsFile.Details("path", e.pathname).Wrap(err)
 	}
 	e.log.Info("saved", zap.String("path", e.pathname))
+	for _, spill := range e.spills {
+		err = spill.Save()
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -264,4 +857,7 @@ func (e *Excel) Close() {
 	}
 	e.log.Info("closed")
 	e.xlFile.Close()
+	for _, spill := range e.spills {
+		spill.Close()
+	}
 }
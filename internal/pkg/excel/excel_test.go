@@ -14,14 +14,25 @@
 package excel
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/kenita8/xlcmd/internal/pkg/excel/excelize"
 	mock_excelize "github.com/kenita8/xlcmd/internal/pkg/excel/excelize/mock"
 	mock_excel "github.com/kenita8/xlcmd/internal/pkg/excel/mock"
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+	"github.com/kenita8/xlcmd/internal/pkg/file/csv"
+	"github.com/kenita8/xlcmd/internal/pkg/file/tsv"
 
 	"github.com/stretchr/testify/assert"
+	rawExcelize "github.com/xuri/excelize/v2"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
 )
@@ -76,6 +87,73 @@ func TestOpen(t *testing.T) {
 	}
 }
 
+func TestOpenWithTemplate(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "report.xltx")
+	f := rawExcelize.NewFile()
+	assert.Nil(t, f.SetCellValue("Sheet1", "A1", "Cover"))
+	_, err := f.NewSheet("data")
+	assert.Nil(t, err)
+	_, err = f.NewSheet("summary")
+	assert.Nil(t, err)
+	assert.Nil(t, f.SetCellFormula("summary", "A1", "SUM(data!B2:B3)"))
+	assert.Nil(t, f.SaveAs(template))
+	f.Close()
+	input := writeTestFile(t, "data.csv", "Name,Value\na,1\nb,2\n")
+
+	testcases := []struct {
+		template     string
+		existing     bool
+		expectSheets []string
+		expectErr    error
+	}{
+		{template: template, expectSheets: []string{"Sheet1", "data", "summary"}},
+		{template: "", expectSheets: []string{"data"}},
+		{template: template, existing: true, expectSheets: []string{"old", "data"}},
+		{template: filepath.Join(dir, "missing.xlsx"), expectErr: ErrOpenTemplate},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			if tc.existing {
+				old := rawExcelize.NewFile()
+				assert.Nil(t, old.SetSheetName("Sheet1", "old"))
+				assert.Nil(t, old.SaveAs(output))
+				old.Close()
+			}
+			e := NewExcel(zap.NewNop())
+			err := e.OpenWithTemplate(output, tc.template)
+			if tc.expectErr != nil {
+				assert.True(t, errors.Is(err, tc.expectErr))
+				return
+			}
+			assert.Nil(t, err)
+			opt := &CellOption{InferTypes: true, Header: true}
+			assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "data", opt, &SheetOption{}))
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			assert.Equal(t, tc.expectSheets, f.GetSheetList())
+			rows, err := f.GetRows("data")
+			assert.Nil(t, err)
+			assert.Equal(t, [][]string{{"Name", "Value"}, {"a", "1"}, {"b", "2"}}, rows)
+			if tc.expectSheets[0] == "Sheet1" {
+				cover, err := f.GetCellValue("Sheet1", "A1")
+				assert.Nil(t, err)
+				assert.Equal(t, "Cover", cover)
+				formula, err := f.GetCellFormula("summary", "A1")
+				assert.Nil(t, err)
+				assert.Equal(t, "SUM(data!B2:B3)", formula)
+			}
+		})
+	}
+}
+
 func TestNewSheet(t *testing.T) {
 	err := fmt.Errorf("ErrorOccurred")
 	testcases := []struct {
@@ -110,6 +188,445 @@ func TestNewSheet(t *testing.T) {
 	}
 }
 
+func writeTestFile(t *testing.T, name string, data string) string {
+	pathname := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(pathname, []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pathname
+}
+
 func TestPasteTxtFile(t *testing.T) {
+	input := writeTestFile(t, "data.csv", "Time,Code,Value,Flag,Id\n"+
+		"07/15/2024 14:11:18.978,007,39.596,true,12345678901234567890\n"+
+		"07/15/2024 14:11:20.011,010,,false,42\n")
+	testcases := []struct {
+		opt          *CellOption
+		expectTypes  []rawExcelize.CellType
+		expectPrefix []string
+	}{
+		{
+			opt: &CellOption{DecimalPlaces: 2, Round: true, InferTypes: true, Header: true},
+			expectTypes: []rawExcelize.CellType{rawExcelize.CellTypeUnset, rawExcelize.CellTypeInlineString,
+				rawExcelize.CellTypeUnset, rawExcelize.CellTypeBool, rawExcelize.CellTypeInlineString},
+			expectPrefix: []string{"45488.59119", "007", "39.6", "1", "12345678901234567890"},
+		},
+		{
+			opt: &CellOption{DecimalPlaces: 2, InferTypes: true, Header: true},
+			expectTypes: []rawExcelize.CellType{rawExcelize.CellTypeUnset, rawExcelize.CellTypeInlineString,
+				rawExcelize.CellTypeUnset, rawExcelize.CellTypeBool, rawExcelize.CellTypeInlineString},
+			expectPrefix: []string{"45488.59119", "007", "39.596", "1", "12345678901234567890"},
+		},
+		{
+			opt: &CellOption{DecimalPlaces: 2, Round: true},
+			expectTypes: []rawExcelize.CellType{rawExcelize.CellTypeInlineString, rawExcelize.CellTypeUnset,
+				rawExcelize.CellTypeUnset, rawExcelize.CellTypeInlineString, rawExcelize.CellTypeUnset},
+			expectPrefix: []string{"07/15/2024 14:11:18.978", "7", "39.6", "true", "12345678901234567000"},
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "data.csv", tc.opt, nil))
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			for col := range tc.expectTypes {
+				cell, _ := rawExcelize.CoordinatesToCellName(col+1, 2)
+				actualType, err := f.GetCellType("data.csv", cell)
+				assert.Nil(t, err)
+				assert.Equal(t, tc.expectTypes[col], actualType, cell)
+				actualValue, err := f.GetCellValue("data.csv", cell, rawExcelize.Options{RawCellValue: true})
+				assert.Nil(t, err)
+				assert.True(t, strings.HasPrefix(actualValue, tc.expectPrefix[col]), cell+": "+actualValue)
+			}
+		})
+	}
+}
+
+func writeBenchmarkTsv(b *testing.B, rows int, cols int) string {
+	pathname := filepath.Join(b.TempDir(), "bench.tsv")
+	f, err := os.Create(pathname)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	values := make([]string, cols)
+	for i := 0; i < rows; i++ {
+		values[0] = fmt.Sprintf("07/15/2024 14:%02d:%02d.978", i/60%60, i%60)
+		for j := 1; j < cols; j++ {
+			values[j] = strconv.FormatFloat(float64(i*j)/7, 'f', -1, 64)
+		}
+		w.WriteString(strings.Join(values, "\t") + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		b.Fatal(err)
+	}
+	return pathname
+}
+
+func reportRetainedHeap(b *testing.B) {
+	var ms runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&ms)
+	b.ReportMetric(float64(ms.HeapAlloc)/(1<<20), "heap-MB")
+}
+
+// BenchmarkSetCellValue writes the input cell by cell into the in-memory model,
+// which is how PasteTxtFile used to work.
+func BenchmarkSetCellValue(b *testing.B) {
+	for _, rows := range []int{1000, 10000, 50000} {
+		b.Run(strconv.Itoa(rows), func(b *testing.B) {
+			pathname := writeBenchmarkTsv(b, rows, 20)
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			opt := &CellOption{DecimalPlaces: 2}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				e := NewExcel(zap.NewNop())
+				e.Open(filepath.Join(b.TempDir(), "out.xlsx"))
+				e.NewSheet("bench.tsv")
+				input := tsv.NewTsvFile(pathname, "UTF-8")
+				input.OpenReadMode()
+				row := 1
+				for {
+					values, err := input.ReadOneLine()
+					if err != nil {
+						break
+					}
+					for col, value := range values {
+						e.SetCellValue(value, "bench.tsv", col+1, row, opt)
+					}
+					row++
+				}
+				input.Close()
+				reportRetainedHeap(b)
+				e.Close()
+			}
+		})
+	}
+}
+
+func BenchmarkPasteTxtFile(b *testing.B) {
+	for _, rows := range []int{1000, 10000, 50000} {
+		b.Run(strconv.Itoa(rows), func(b *testing.B) {
+			pathname := writeBenchmarkTsv(b, rows, 20)
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			opt := &CellOption{DecimalPlaces: 2}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				e := NewExcel(zap.NewNop())
+				e.Open(filepath.Join(b.TempDir(), "out.xlsx"))
+				err := e.PasteTxtFile(tsv.NewTsvFile(pathname, "UTF-8"), "bench.tsv", opt, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				reportRetainedHeap(b)
+				e.Close()
+			}
+		})
+	}
+}
+
+func TestPasteTxtFileSheetOption(t *testing.T) {
+	input := writeTestFile(t, "data.csv", "Time,Value,Value\n2024-01-01,1,2\n2024-01-02,3,4\n")
+	testcases := []struct {
+		sheetOpt     *SheetOption
+		expectFreeze bool
+		expectBold   bool
+		expectFilter string
+		expectTable  string
+	}{
+		{
+			sheetOpt: &SheetOption{},
+		},
+		{
+			sheetOpt:     &SheetOption{HeaderStyle: true, FreezeHeader: true, AutoFilter: true},
+			expectFreeze: true,
+			expectBold:   true,
+			expectFilter: "'data.csv'!$A$1:$C$3",
+		},
+		{
+			sheetOpt:    &SheetOption{AutoFilter: true, TableStyle: "TableStyleMedium2"},
+			expectTable: "A1:C3",
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			opt := &CellOption{InferTypes: true, Header: true}
+			assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "data.csv", opt, tc.sheetOpt))
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			panes, err := f.GetPanes("data.csv")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectFreeze, panes.Freeze)
+			styleID, err := f.GetCellStyle("data.csv", "B1")
+			assert.Nil(t, err)
+			style, err := f.GetStyle(styleID)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectBold, style.Font != nil && style.Font.Bold)
+			filter := ""
+			for _, name := range f.GetDefinedName() {
+				if name.Scope == "data.csv" {
+					filter = name.RefersTo
+				}
+			}
+			assert.Equal(t, tc.expectFilter, filter)
+			tables, err := f.GetTables("data.csv")
+			assert.Nil(t, err)
+			if len(tc.expectTable) > 0 {
+				assert.Len(t, tables, 1)
+				assert.Equal(t, tc.expectTable, tables[0].Range)
+				header, _ := f.GetCellValue("data.csv", "C1")
+				assert.Equal(t, "Value 2", header)
+			} else {
+				assert.Len(t, tables, 0)
+			}
+		})
+	}
+}
+
+func TestPasteTxtFileStartCell(t *testing.T) {
+	input := writeTestFile(t, "data.csv", "Time,Value\n2024-01-01,1\n2024-01-02,3\n")
+	testcases := []struct {
+		sheet        string
+		sheetOpt     *SheetOption
+		expectRows   [][]string
+		expectFilter string
+		expectTable  string
+	}{
+		{
+			sheet:        "report",
+			sheetOpt:     &SheetOption{StartCell: "B5", HeaderStyle: true, AutoFilter: true},
+			expectRows:   [][]string{{"Title"}, {"2024"}, nil, nil, {"", "Time", "Value"}, {"", "2024-01-01", "1"}, {"", "2024-01-02", "3"}},
+			expectFilter: "'report'!$B$5:$C$7",
+		},
+		{
+			sheet:       "report",
+			sheetOpt:    &SheetOption{StartCell: "B5", HeaderStyle: true, TableStyle: "TableStyleMedium2"},
+			expectRows:  [][]string{{"Title"}, {"2024"}, nil, nil, {"", "Time", "Value"}, {"", "2024-01-01", "1"}, {"", "2024-01-02", "3"}},
+			expectTable: "B5:C7",
+		},
+		{
+			sheet:        "new",
+			sheetOpt:     &SheetOption{StartCell: "B5", HeaderStyle: true, AutoFilter: true},
+			expectRows:   [][]string{nil, nil, nil, nil, {"", "Time", "Value"}, {"", "2024-01-01", "1"}, {"", "2024-01-02", "3"}},
+			expectFilter: "'new'!$B$5:$C$7",
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			template := rawExcelize.NewFile()
+			_, err := template.NewSheet("report")
+			assert.Nil(t, err)
+			assert.Nil(t, template.SetSheetRow("report", "A1", &[]interface{}{"Title"}))
+			assert.Nil(t, template.SetSheetRow("report", "A2", &[]interface{}{"2024"}))
+			assert.Nil(t, template.SaveAs(output))
+			template.Close()
+
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			opt := &CellOption{InferTypes: true, Header: true}
+			assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), tc.sheet, opt, tc.sheetOpt))
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			rows, err := f.GetRows(tc.sheet)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectRows, rows)
+			for cell, bold := range map[string]bool{"A5": false, "B5": true, "C5": true, "D5": false, "B6": false} {
+				styleID, err := f.GetCellStyle(tc.sheet, cell)
+				assert.Nil(t, err)
+				style, err := f.GetStyle(styleID)
+				assert.Nil(t, err)
+				assert.Equal(t, bold, style.Font != nil && style.Font.Bold, cell)
+			}
+			filter := ""
+			for _, name := range f.GetDefinedName() {
+				if name.Scope == tc.sheet {
+					filter = name.RefersTo
+				}
+			}
+			assert.Equal(t, tc.expectFilter, filter)
+			tables, err := f.GetTables(tc.sheet)
+			assert.Nil(t, err)
+			ranges := []string{}
+			for _, table := range tables {
+				ranges = append(ranges, table.Range)
+			}
+			if len(tc.expectTable) > 0 {
+				assert.Equal(t, []string{tc.expectTable}, ranges)
+			} else {
+				assert.Len(t, ranges, 0)
+			}
+		})
+	}
+}
 
+// TestPasteTxtFileStartCellReplace replaces the input below a title block with
+// a shorter one, which must not leave the rows of the first run.
+func TestPasteTxtFileStartCellReplace(t *testing.T) {
+	long := writeTestFile(t, "long.csv", "Time,Value\n2024-01-01,1\n2024-01-02,3\n2024-01-03,5\n2024-01-04,7\n")
+	short := writeTestFile(t, "short.csv", "Time,Value\n2024-02-01,2\n")
+	filer = &file.File{}
+	excelizer = &excelize.Excelize{}
+	output := filepath.Join(t.TempDir(), "output.xlsx")
+	template := rawExcelize.NewFile()
+	_, err := template.NewSheet("report")
+	assert.Nil(t, err)
+	assert.Nil(t, template.SetSheetRow("report", "A1", &[]interface{}{"Title"}))
+	assert.Nil(t, template.SetSheetRow("report", "A6", &[]interface{}{"Note"}))
+	assert.Nil(t, template.SaveAs(output))
+	template.Close()
+
+	for _, input := range []string{long, short} {
+		e := NewExcel(zap.NewNop())
+		assert.Nil(t, e.Open(output))
+		opt := &CellOption{InferTypes: true, Header: true}
+		sheetOpt := &SheetOption{StartCell: "B5", Mode: PasteModeReplace}
+		assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "report", opt, sheetOpt))
+		assert.Nil(t, e.Save())
+		e.Close()
+	}
+
+	f, err := rawExcelize.OpenFile(output)
+	assert.Nil(t, err)
+	defer f.Close()
+	rows, err := f.GetRows("report")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"Title"}, nil, nil, nil, {"", "Time", "Value"}, {"Note", "2024-02-01", "2"}}, rows)
+}
+
+func TestPasteTxtFileMode(t *testing.T) {
+	existing := writeTestFile(t, "existing.csv", "Name,Value\na,1\nb,2\nc,3\n")
+	input := writeTestFile(t, "input.csv", "Name,Value\nd,4\n")
+	old := [][]string{{"Name", "Value"}, {"a", "1"}, {"b", "2"}, {"c", "3"}}
+	testcases := []struct {
+		sheet        string
+		sheetOpt     *SheetOption
+		expectRows   [][]string
+		expectTables []string
+	}{
+		{
+			sheet:        "data",
+			sheetOpt:     &SheetOption{Mode: PasteModeReplace, TableStyle: "TableStyleMedium2"},
+			expectRows:   [][]string{{"Name", "Value"}, {"d", "4"}},
+			expectTables: []string{"A1:B2"},
+		},
+		{
+			sheet:        "data",
+			sheetOpt:     &SheetOption{Mode: PasteModeAppend, TableStyle: "TableStyleMedium2"},
+			expectRows:   append(old, []string{"Name", "Value"}, []string{"d", "4"}),
+			expectTables: []string{"A1:B6"},
+		},
+		{
+			sheet:        "data",
+			sheetOpt:     &SheetOption{Mode: PasteModeAppend, SkipHeader: true},
+			expectRows:   append(old, []string{"d", "4"}),
+			expectTables: []string{"A1:B5"},
+		},
+		{
+			sheet:        "data",
+			sheetOpt:     &SheetOption{Mode: PasteModeSkip},
+			expectRows:   old,
+			expectTables: []string{"A1:B4"},
+		},
+		{
+			sheet:        "new",
+			sheetOpt:     &SheetOption{Mode: PasteModeAppend, SkipHeader: true},
+			expectRows:   [][]string{{"Name", "Value"}, {"d", "4"}},
+			expectTables: []string{},
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			opt := &CellOption{InferTypes: true, Header: true}
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(existing, "UTF-8"), "data", opt, &SheetOption{TableStyle: "TableStyleMedium2"}))
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			e = NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), tc.sheet, opt, tc.sheetOpt))
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			rows, err := f.GetRows(tc.sheet)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectRows, rows)
+			tables, err := f.GetTables(tc.sheet)
+			assert.Nil(t, err)
+			ranges := []string{}
+			for _, table := range tables {
+				ranges = append(ranges, table.Range)
+			}
+			assert.Equal(t, tc.expectTables, ranges)
+		})
+	}
+}
+
+func TestPasteTxtFileAppendAutoFilter(t *testing.T) {
+	filer = &file.File{}
+	excelizer = &excelize.Excelize{}
+	existing := writeTestFile(t, "existing.csv", "Name,Value\na,1\nb,2\n")
+	input := writeTestFile(t, "input.csv", "Name,Value\nc,3\nd,4\n")
+	output := filepath.Join(t.TempDir(), "output.xlsx")
+	opt := &CellOption{InferTypes: true, Header: true}
+
+	e := NewExcel(zap.NewNop())
+	assert.Nil(t, e.Open(output))
+	assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(existing, "UTF-8"), "data", opt, &SheetOption{AutoFilter: true}))
+	assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(existing, "UTF-8"), "other", opt, &SheetOption{AutoFilter: true}))
+	assert.Nil(t, e.Save())
+	e.Close()
+
+	e = NewExcel(zap.NewNop())
+	assert.Nil(t, e.Open(output))
+	assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "data", opt, &SheetOption{Mode: PasteModeAppend, SkipHeader: true}))
+	assert.Nil(t, e.Save())
+	e.Close()
+
+	f, err := rawExcelize.OpenFile(output)
+	assert.Nil(t, err)
+	defer f.Close()
+	filters := map[string]string{}
+	for _, name := range f.GetDefinedName() {
+		if name.Name == filterCriteria {
+			filters[name.Scope] = name.RefersTo
+		}
+	}
+	assert.Equal(t, map[string]string{"data": "'data'!$A$1:$B$5", "other": "'other'!$A$1:$B$3"}, filters)
 }
@@ -13,7 +13,7 @@
 // limitations under the License.
 package excelize
 
-import "github.com/kenita8/errors"
+import "github.com/kenita8/xlcmd/internal/pkg/errors"
 
 var (
 	ErrChartType = errors.New("invalid chart type")
@@ -30,9 +30,19 @@ type ExcelizeFiler interface {
 	SaveAs(filename string, opts ...excelize.Options) error
 	Save(opts ...excelize.Options) error
 	SetCellStyle(sheet, topLeftCell, bottomRightCell string, styleID int) error
+	SetColWidth(sheet, startCol, endCol string, width float64) error
+	NewStyle(style *excelize.Style) (int, error)
+	SetPanes(sheet string, panes *excelize.Panes) error
+	AutoFilter(sheet, rangeRef string, opts []excelize.AutoFilterOptions) error
 	AddChart(sheet, cell string, chart *excelize.Chart, combo ...*excelize.Chart) error
 	Cols(sheet string) (*excelize.Cols, error)
 	Rows(sheet string) (*excelize.Rows, error)
+	NewStreamWriter(sheet string) (*excelize.StreamWriter, error)
+	AddTable(sheet string, table *excelize.Table) error
+	GetTables(sheet string) ([]excelize.Table, error)
+	DeleteTable(name string) error
+	GetDefinedName() []excelize.DefinedName
+	UpdateLinkedValue() error
 	Close() error
 }
 
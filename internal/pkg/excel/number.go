@@ -0,0 +1,209 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// NumberLocale is how numbers are written in the input. Thousands holds every
+// character accepted between digit groups, and may be empty.
+type NumberLocale struct {
+	Decimal   rune
+	Thousands string
+}
+
+const (
+	// SpaceSeparators are the spaces used as thousands separators: space,
+	// no-break space and narrow no-break space.
+	SpaceSeparators = " \u00a0\u202f"
+)
+
+var (
+	// Locales are the number conventions known by language or language-region
+	// name. Names are lower case with "-" between language and region.
+	Locales = map[string]NumberLocale{
+		"en":    {Decimal: '.', Thousands: ","},
+		"ja":    {Decimal: '.', Thousands: ","},
+		"zh":    {Decimal: '.', Thousands: ","},
+		"ko":    {Decimal: '.', Thousands: ","},
+		"de":    {Decimal: ',', Thousands: "."},
+		"es":    {Decimal: ',', Thousands: "."},
+		"it":    {Decimal: ',', Thousands: "."},
+		"nl":    {Decimal: ',', Thousands: "."},
+		"pt":    {Decimal: ',', Thousands: "."},
+		"da":    {Decimal: ',', Thousands: "."},
+		"fr":    {Decimal: ',', Thousands: SpaceSeparators},
+		"ru":    {Decimal: ',', Thousands: SpaceSeparators},
+		"pl":    {Decimal: ',', Thousands: SpaceSeparators},
+		"sv":    {Decimal: ',', Thousands: SpaceSeparators},
+		"fi":    {Decimal: ',', Thousands: SpaceSeparators},
+		"nb":    {Decimal: ',', Thousands: SpaceSeparators},
+		"de-ch": {Decimal: '.', Thousands: "'’"},
+	}
+	// currencySymbols are recognized before or after a number.
+	currencySymbols = []string{"$", "€", "£", "¥", "￥", "₩", "₹", "₽", "₺", "₫", "₱"}
+)
+
+// LookupLocale finds the number conventions of a locale name such as "de",
+// "de-DE", "de_DE" or "de_DE.UTF-8", falling back to the language alone.
+func LookupLocale(name string) (*NumberLocale, bool) {
+	name = strings.ToLower(name)
+	if i := strings.IndexAny(name, ".@"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.ReplaceAll(name, "_", "-")
+	if loc, ok := Locales[name]; ok {
+		return &loc, true
+	}
+	lang, _, _ := strings.Cut(name, "-")
+	if loc, ok := Locales[lang]; ok {
+		return &loc, true
+	}
+	return nil, false
+}
+
+// localeNumber is a number text taken apart by parseLocaleNumber.
+type localeNumber struct {
+	// text is the number in the form strconv reads, without percent.
+	text      string
+	decimals  int
+	grouped   bool
+	percent   bool
+	currency  string
+	prefixed  bool
+	separated bool
+}
+
+// parseLocaleNumber reads text such as "1.234,5", "¥1,200", "-12,50 €" or
+// "45%" written with the conventions of loc.
+func parseLocaleNumber(text string, loc *NumberLocale) (*localeNumber, bool) {
+	n := &localeNumber{}
+	s := strings.TrimSpace(text)
+	if rest, ok := strings.CutSuffix(s, "%"); ok {
+		n.percent = true
+		s = strings.TrimSpace(rest)
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+	if !n.percent {
+		for _, symbol := range currencySymbols {
+			if rest, ok := strings.CutPrefix(s, symbol); ok {
+				n.currency, n.prefixed = symbol, true
+				s = rest
+				break
+			}
+			if rest, ok := strings.CutSuffix(s, symbol); ok {
+				n.currency = symbol
+				s = rest
+				break
+			}
+		}
+		if len(n.currency) > 0 {
+			trimmed := strings.TrimSpace(s)
+			n.separated = trimmed != s
+			s = trimmed
+		}
+	}
+	if len(sign) <= 0 && (strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+")) {
+		sign, s = s[:1], s[1:]
+	}
+	intPart, fracPart, hasDecimal := strings.Cut(s, string(loc.Decimal))
+	digits, grouped, ok := ungroup(intPart, loc.Thousands)
+	if !ok || (hasDecimal && !allDigits(fracPart)) || len(digits)+len(fracPart) <= 0 {
+		return nil, false
+	}
+	if len(digits) <= 0 {
+		digits = "0"
+	}
+	n.text = sign + digits
+	if hasDecimal && len(fracPart) > 0 {
+		n.text += "." + fracPart
+	}
+	n.decimals = len(fracPart)
+	n.grouped = grouped
+	return n, true
+}
+
+// ungroup removes thousands separators from the integer part. Every group
+// after the first must have three digits.
+func ungroup(s string, thousands string) (string, bool, bool) {
+	if allDigits(s) {
+		return s, false, true
+	}
+	var sb strings.Builder
+	group := 0
+	groups := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+			group++
+			continue
+		}
+		if !strings.ContainsRune(thousands, r) || group <= 0 || (groups == 0 && group > 3) || (groups > 0 && group != 3) {
+			return "", false, false
+		}
+		groups++
+		group = 0
+	}
+	if group != 3 {
+		return "", false, false
+	}
+	return sb.String(), true, true
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// numFmt is the number format that shows the number the way it was written.
+// A plain number needs none.
+func (n *localeNumber) numFmt() string {
+	decimals := ""
+	if n.decimals > 0 {
+		decimals = "." + strings.Repeat("0", n.decimals)
+	}
+	if n.percent {
+		return "0" + decimals + "%"
+	}
+	if len(n.currency) > 0 {
+		symbol := strconv.Quote(n.currency)
+		if n.separated {
+			if n.prefixed {
+				symbol += " "
+			} else {
+				symbol = " " + symbol
+			}
+		}
+		if n.prefixed {
+			return symbol + "#,##0" + decimals
+		}
+		return "#,##0" + decimals + symbol
+	}
+	if n.grouped {
+		return "#,##0" + decimals
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupLocale(t *testing.T) {
+	testcases := []struct {
+		name     string
+		expect   *NumberLocale
+		expectOk bool
+	}{
+		{"en", &NumberLocale{Decimal: '.', Thousands: ","}, true},
+		{"de_DE.UTF-8", &NumberLocale{Decimal: ',', Thousands: "."}, true},
+		{"de-CH", &NumberLocale{Decimal: '.', Thousands: "'’"}, true},
+		{"fr-CA", &NumberLocale{Decimal: ',', Thousands: SpaceSeparators}, true},
+		{"xx", nil, false},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, actualOk := LookupLocale(tc.name)
+			assert.Equal(t, tc.expectOk, actualOk)
+			assert.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestLocaleCellValue(t *testing.T) {
+	en, _ := LookupLocale("en")
+	de, _ := LookupLocale("de")
+	fr, _ := LookupLocale("fr")
+	testcases := []struct {
+		value  string
+		number *NumberLocale
+		places int
+		round  bool
+		expect interface{}
+	}{
+		{"1,234.5", en, -1, false, formattedValue{value: 1234.5, numFmt: "#,##0.0"}},
+		{"1.234,5", de, -1, false, formattedValue{value: 1234.5, numFmt: "#,##0.0"}},
+		{"1 234,5", fr, -1, false, formattedValue{value: 1234.5, numFmt: "#,##0.0"}},
+		{"12,5", de, -1, false, 12.5},
+		{"-7", de, -1, false, -7.0},
+		{"¥1,200", en, -1, false, formattedValue{value: 1200.0, numFmt: `"¥"#,##0`}},
+		{"-$3.50", en, -1, false, formattedValue{value: -3.5, numFmt: `"$"#,##0.00`}},
+		{"12,50 €", de, -1, false, formattedValue{value: 12.5, numFmt: `#,##0.00 "€"`}},
+		{"45%", en, -1, false, formattedValue{value: 0.45, numFmt: "0%"}},
+		{"12,5 %", de, -1, false, formattedValue{value: 0.125, numFmt: "0.0%"}},
+		{"33.333%", en, 1, true, formattedValue{value: 0.333, numFmt: "0.0%"}},
+		{"33.333%", en, 1, false, formattedValue{value: 0.33333, numFmt: "0.0%"}},
+		{"12,5", de, 2, false, formattedValue{value: 12.5, numFmt: "0.0"}},
+		{"12,346", de, 2, true, 12.35},
+		{"1,23", en, -1, false, "1,23"},
+		{"1.5", de, -1, false, "1.5"},
+		{"12,34,567", en, -1, false, "12,34,567"},
+		{"1e3", en, -1, false, "1e3"},
+		{"abc", en, -1, false, "abc"},
+		{"", en, -1, false, ""},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			opt := &CellOption{DecimalPlaces: tc.places, Round: tc.round, Number: tc.number}
+			assert.Equal(t, tc.expect, cellValue(tc.value, opt))
+		})
+	}
+}
+
+func TestColumnTypeLocale(t *testing.T) {
+	de, _ := LookupLocale("de")
+	testcases := []struct {
+		values []string
+		expect ColumnType
+	}{
+		{[]string{"1", "20"}, ColumnType{Type: CellTypeInt, NumFmt: "0"}},
+		{[]string{"1.234,5", "3", "45 %"}, ColumnType{Type: CellTypeFloat}},
+		{[]string{"1.5", "2"}, ColumnType{Type: CellTypeText}},
+		{[]string{"1234567890123456789"}, ColumnType{Type: CellTypeText}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			guess := newColumnGuess(DefaultDateLayouts, de)
+			for _, value := range tc.values {
+				guess.add(value)
+			}
+			assert.Equal(t, tc.expect, guess.columnType())
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"strconv"
+	"strings"
+
+	rawExcelize "github.com/xuri/excelize/v2"
+)
+
+// NumberFormat is the Excel number format code of the columns picked by
+// Column, e.g. "0.00%", "#,##0" or "yyyy-mm-dd hh:mm:ss". It changes how the
+// cells are shown, not the values stored.
+type NumberFormat struct {
+	Column ColumnSelector
+	Code   string
+}
+
+// ParseNumberFormat reads a column and its format code separated by "=", e.g.
+// "Rate=0.00%", "3=#,##0" or "/Time$/=yyyy-mm-dd hh:mm:ss". The column is a
+// 1-based index of the written columns, after Columns picks them, a header
+// name or a regular expression between slashes.
+func ParseNumberFormat(text string) (NumberFormat, error) {
+	trimmed := strings.TrimSpace(text)
+	end := 0
+	if strings.HasPrefix(trimmed, "/") {
+		end = patternEnd(trimmed)
+		if end < 0 {
+			return NumberFormat{}, ErrInvalidNumberFormat.Details("format", text, "reason", "regex is not closed")
+		}
+	}
+	column, code, ok := strings.Cut(trimmed[end:], "=")
+	if !ok {
+		return NumberFormat{}, ErrInvalidNumberFormat.Details("format", text, "reason", "format must be column=code")
+	}
+	column = strings.TrimSpace(trimmed[:end] + column)
+	sel := ColumnSelector{}
+	switch {
+	case len(column) <= 0:
+		return NumberFormat{}, ErrInvalidNumberFormat.Details("format", text, "reason", "column is empty")
+	case end > 0:
+		if end != len(column)-1 {
+			return NumberFormat{}, ErrInvalidNumberFormat.Details("format", text, "reason", "unexpected text after regex")
+		}
+		sel.Pattern = strings.ReplaceAll(column[1:end], `\/`, "/")
+	default:
+		if n, err := strconv.Atoi(column); err == nil {
+			if n <= 0 {
+				return NumberFormat{}, ErrInvalidNumberFormat.Details("format", text, "reason", "index must be 1 or more")
+			}
+			sel.Index = n
+		} else {
+			sel.Name = column
+		}
+	}
+	if len(strings.TrimSpace(code)) <= 0 {
+		return NumberFormat{}, ErrInvalidNumberFormat.Details("format", text, "reason", "format code is empty")
+	}
+	columns := &Columns{Selectors: []ColumnSelector{sel}}
+	err := columns.validate()
+	if err != nil {
+		return NumberFormat{}, ErrInvalidNumberFormat.Details("format", text, "reason", err.Error())
+	}
+	return NumberFormat{Column: columns.Selectors[0], Code: code}, nil
+}
+
+// ByHeader tells whether the format needs the header row to find its columns.
+func (f *NumberFormat) ByHeader() bool {
+	return len(f.Column.Name) > 0 || len(f.Column.Pattern) > 0
+}
+
+// decimalsNumFmt is the number format that shows opt.DecimalPlaces decimals,
+// or "" when numbers are shown as they are stored.
+func decimalsNumFmt(opt *CellOption) string {
+	if opt == nil || opt.Round || opt.DecimalPlaces < 0 {
+		return ""
+	}
+	if opt.DecimalPlaces == 0 {
+		return "0"
+	}
+	return "0." + strings.Repeat("0", opt.DecimalPlaces)
+}
+
+// numberFormats is NumberFormats resolved by the first row of an input. The
+// last format of a column wins.
+type numberFormats struct {
+	formats []NumberFormat
+	header  bool
+	codes   []string
+}
+
+func newNumberFormats(opt *CellOption) *numberFormats {
+	if opt == nil || len(opt.NumberFormats) <= 0 {
+		return nil
+	}
+	return &numberFormats{formats: opt.NumberFormats, header: opt.Header}
+}
+
+// format gives the data cells of a row the number format of their column.
+func (n *numberFormats) format(cells []interface{}, values []string, first bool) {
+	if n == nil {
+		return
+	}
+	n.resolveRow(values, first)
+	if first && n.header {
+		return
+	}
+	for col, code := range n.codes {
+		if col >= len(cells) || len(code) <= 0 {
+			continue
+		}
+		switch c := cells[col].(type) {
+		case nil:
+		case string:
+			if len(c) > 0 {
+				cells[col] = formattedValue{value: c, numFmt: code}
+			}
+		case rawExcelize.Cell:
+			cells[col] = formattedValue{value: c.Value, numFmt: code}
+		case formattedValue:
+			c.numFmt = code
+			cells[col] = c
+		default:
+			cells[col] = formattedValue{value: c, numFmt: code}
+		}
+	}
+}
+
+// resolveRow resolves the formats with the text of a row when it is the first.
+func (n *numberFormats) resolveRow(values []string, first bool) {
+	if n == nil || n.codes != nil {
+		return
+	}
+	var header []string
+	if first && n.header {
+		header = values
+	}
+	n.resolve(header, len(values))
+}
+
+// code is the number format of the col-th column, or "" when it has none.
+func (n *numberFormats) code(col int) string {
+	if n == nil || col >= len(n.codes) {
+		return ""
+	}
+	return n.codes[col]
+}
+
+func (n *numberFormats) resolve(header []string, cols int) {
+	n.codes = make([]string, max(cols, len(header)))
+	for _, f := range n.formats {
+		sel := f.Column
+		switch {
+		case sel.Index > 0:
+			for len(n.codes) < sel.Index {
+				n.codes = append(n.codes, "")
+			}
+			n.codes[sel.Index-1] = f.Code
+		case sel.regexp != nil:
+			for col, name := range header {
+				if sel.regexp.MatchString(name) {
+					n.codes[col] = f.Code
+				}
+			}
+		default:
+			for col, name := range header {
+				if name == sel.Name {
+					n.codes[col] = f.Code
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/kenita8/xlcmd/internal/pkg/excel/excelize"
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+	"github.com/kenita8/xlcmd/internal/pkg/file/csv"
+
+	"github.com/stretchr/testify/assert"
+	rawExcelize "github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+func TestParseNumberFormat(t *testing.T) {
+	testcases := []struct {
+		text      string
+		expect    NumberFormat
+		expectErr error
+	}{
+		{"Rate=0.00%", NumberFormat{Column: ColumnSelector{Name: "Rate"}, Code: "0.00%"}, nil},
+		{" 3 =#,##0", NumberFormat{Column: ColumnSelector{Index: 3}, Code: "#,##0"}, nil},
+		{`/^T\/=/=yyyy-mm-dd hh:mm:ss`, NumberFormat{Column: ColumnSelector{Pattern: "^T/="}, Code: "yyyy-mm-dd hh:mm:ss"}, nil},
+		{"Flag=[=1]\"yes\";\"no\"", NumberFormat{Column: ColumnSelector{Name: "Flag"}, Code: "[=1]\"yes\";\"no\""}, nil},
+		{"Rate", NumberFormat{}, ErrInvalidNumberFormat},
+		{"=0.00", NumberFormat{}, ErrInvalidNumberFormat},
+		{"Rate=", NumberFormat{}, ErrInvalidNumberFormat},
+		{"0=0.00", NumberFormat{}, ErrInvalidNumberFormat},
+		{"/abc=0.00", NumberFormat{}, ErrInvalidNumberFormat},
+		{"/abc/x=0.00", NumberFormat{}, ErrInvalidNumberFormat},
+		{"/(/=0.00", NumberFormat{}, ErrInvalidNumberFormat},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := ParseNumberFormat(tc.text)
+			if tc.expectErr != nil {
+				assert.True(t, errors.Is(err, tc.expectErr))
+				return
+			}
+			assert.Nil(t, err)
+			actual.Column.regexp = nil
+			assert.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func parseNumberFormats(t *testing.T, texts ...string) []NumberFormat {
+	formats := []NumberFormat{}
+	for _, text := range texts {
+		format, err := ParseNumberFormat(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		formats = append(formats, format)
+	}
+	return formats
+}
+
+func TestPasteTxtFileNumberFormat(t *testing.T) {
+	input := writeTestFile(t, "data.csv", "Time,Rate,Amount,Name\n"+
+		"2024-07-01 10:00:00.5,0.12345,1234.5678,a\n"+
+		"2024-07-01 10:00:01,0.5,2,b\n")
+	type cellFormat struct {
+		value  string
+		numFmt string
+	}
+	testcases := []struct {
+		opt    *CellOption
+		expect map[string]cellFormat
+	}{
+		{
+			opt: &CellOption{DecimalPlaces: 2, InferTypes: true, Header: true},
+			expect: map[string]cellFormat{
+				"A2": {"45474.416672453706", "yyyy-mm-dd hh:mm:ss.000"},
+				"B2": {"0.12345", "0.00"},
+				"C2": {"1234.5678", "0.00"},
+				"C3": {"2", "0.00"},
+				"D2": {"a", ""},
+			},
+		},
+		{
+			opt: &CellOption{DecimalPlaces: 2, Round: true, InferTypes: true, Header: true},
+			expect: map[string]cellFormat{
+				"B2": {"0.12", ""},
+				"C2": {"1234.57", ""},
+				"C3": {"2", ""},
+			},
+		},
+		{
+			opt: &CellOption{DecimalPlaces: 2, InferTypes: true, Header: true, NumberFormats: parseNumberFormats(t,
+				"/^Ti/=yyyy-mm-dd", "Rate=0.00%", "3=0.0", "Amount=#,##0", "Other=0")},
+			expect: map[string]cellFormat{
+				"A1": {"Time", ""},
+				"A2": {"45474.416672453706", "yyyy-mm-dd"},
+				"B1": {"Rate", ""},
+				"B2": {"0.12345", "0.00%"},
+				"C2": {"1234.5678", "#,##0"},
+				"C3": {"2", "#,##0"},
+			},
+		},
+		{
+			opt: &CellOption{DecimalPlaces: -1, NumberFormats: parseNumberFormats(t, "2=0.0%")},
+			expect: map[string]cellFormat{
+				"B1": {"Rate", "0.0%"},
+				"B2": {"0.12345", "0.0%"},
+				"C2": {"1234.5678", ""},
+			},
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "data.csv", tc.opt, nil))
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			for cell, expect := range tc.expect {
+				value, err := f.GetCellValue("data.csv", cell, rawExcelize.Options{RawCellValue: true})
+				assert.Nil(t, err)
+				assert.Equal(t, expect.value, value, cell)
+				styleID, err := f.GetCellStyle("data.csv", cell)
+				assert.Nil(t, err)
+				style, err := f.GetStyle(styleID)
+				assert.Nil(t, err)
+				numFmt := ""
+				if style.CustomNumFmt != nil {
+					numFmt = *style.CustomNumFmt
+				}
+				assert.Equal(t, expect.numFmt, numFmt, cell)
+			}
+		})
+	}
+}
+
+func TestCellValueDecimals(t *testing.T) {
+	testcases := []struct {
+		value  string
+		places int
+		round  bool
+		expect interface{}
+	}{
+		{"12.5", 2, false, formattedValue{value: 12.5, numFmt: "0.00"}},
+		{"12", 2, false, 12.0},
+		{"-3", 0, false, -3.0},
+		{"1e3", 1, false, formattedValue{value: 1000.0, numFmt: "0.0"}},
+		{"12", -1, false, 12.0},
+		{"12.346", 2, true, 12.35},
+		{"12", 2, true, 12.0},
+		{"abc", 2, false, "abc"},
+		{"", 2, false, ""},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			opt := &CellOption{DecimalPlaces: tc.places, Round: tc.round}
+			assert.Equal(t, tc.expect, cellValue(tc.value, opt))
+		})
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/kenita8/xlcmd/internal/pkg/file/txt"
+	"go.uber.org/zap"
+)
+
+const (
+	// parsedRowBuffer is the number of rows read ahead of the writer per input.
+	parsedRowBuffer = 256
+)
+
+// PasteJob is an input of PasteTxtFiles and the sheet it is written to.
+type PasteJob struct {
+	Txt         txt.TxtFiler
+	Sheet       string
+	CellOption  *CellOption
+	SheetOption *SheetOption
+}
+
+type parsedRow struct {
+	// first is set on the first row written, which is the header row when
+	// there is one.
+	first bool
+	// values keeps the text of the first row, which may be written as a
+	// header.
+	values   []string
+	cells    []interface{}
+	rejected *RejectedRow
+}
+
+// readError is a failure to open or read an input, which wrapReadError wraps
+// with the name of the input.
+type readError struct {
+	err error
+}
+
+func (r *readError) Error() string {
+	return r.err.Error()
+}
+
+func wrapReadError(txt txt.TxtFiler, err error) error {
+	var re *readError
+	if errors.As(err, &re) {
+		return ErrReadInputFile.Details("file", txt.Filename()).Wrap(re.err)
+	}
+	var ce *columnError
+	if errors.As(err, &ce) {
+		return ErrColumnNotFound.Details("file", txt.Filename(), "column", ce.column)
+	}
+	return err
+}
+
+// parsedTxt is an input being read and converted on its own goroutine.
+// profile and openErr are set before ready is closed, err before rows is.
+type parsedTxt struct {
+	profile *TxtProfile
+	openErr error
+	ready   chan struct{}
+	rows    chan parsedRow
+	stop    chan struct{}
+	exited  chan struct{}
+	err     error
+}
+
+func newParsedTxt() *parsedTxt {
+	return &parsedTxt{
+		ready:  make(chan struct{}),
+		rows:   make(chan parsedRow, parsedRowBuffer),
+		stop:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+}
+
+// parseTxtFile reads and converts the input of job. It does not touch the
+// workbook, so it can run while other inputs are written.
+func parseTxtFile(job *PasteJob, p *parsedTxt) {
+	defer close(p.exited)
+	defer close(p.rows)
+	select {
+	case <-p.stop:
+		// the sheet is skipped
+		close(p.ready)
+		return
+	default:
+	}
+	opt := job.CellOption
+	sheetOpt := job.SheetOption
+	autoFit := sheetOpt != nil && sheetOpt.AutoFit
+	if (opt != nil && opt.InferTypes) || (sheetOpt != nil && sheetOpt.AutoFilter) || autoFit {
+		p.profile, p.openErr = profileTxtFile(job.Txt, opt, autoFit)
+	}
+	if p.openErr == nil {
+		err := job.Txt.OpenReadMode()
+		if err != nil {
+			p.openErr = &readError{err: err}
+		}
+	}
+	close(p.ready)
+	if p.openErr != nil {
+		return
+	}
+	defer job.Txt.Close()
+	typed := p.profile != nil && p.profile.Types != nil
+	policy := onError(opt)
+	reader := newRowReader(job.Txt, opt)
+	formats := newNumberFormats(opt)
+	for {
+		values, rejected, err := reader.next()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			p.err = err
+			return
+		}
+		if rejected != nil {
+			// The writer fails on it unless bad rows are left out.
+			if !p.send(parsedRow{rejected: rejected}) || policy == OnErrorFail {
+				return
+			}
+			continue
+		}
+		row := parsedRow{first: reader.first(), cells: make([]interface{}, len(values))}
+		if row.first {
+			row.values = values
+		}
+		for col, value := range values {
+			if typed {
+				row.cells[col] = typedCellValue(value, col, p.profile.Types, opt)
+			} else {
+				row.cells[col] = cellValue(value, opt)
+			}
+		}
+		formats.format(row.cells, values, row.first)
+		if !p.send(row) {
+			return
+		}
+	}
+}
+
+// rowReader reads the records of an input and picks the rows to write, so
+// that every pass over the input sees the same rows.
+type rowReader struct {
+	txt    txt.TxtFiler
+	policy ErrorPolicy
+	filter *rowFilter
+	proj   *projection
+	header bool
+	// record is the number of records read, and written the number of rows
+	// returned.
+	record  int
+	written int
+}
+
+func newRowReader(txt txt.TxtFiler, opt *CellOption) *rowReader {
+	return &rowReader{
+		txt:    txt,
+		policy: onError(opt),
+		filter: newRowFilter(opt),
+		proj:   newProjection(opt),
+		header: opt != nil && opt.Header,
+	}
+}
+
+// next returns the next row to write, or the next record left out.
+func (r *rowReader) next() ([]string, *RejectedRow, error) {
+	for {
+		values, err := r.txt.ReadOneLine()
+		if err == io.EOF {
+			return nil, nil, io.EOF
+		}
+		var rowErr *txt.RowError
+		if err != nil && (r.policy == OnErrorFail || !errors.As(err, &rowErr)) {
+			return nil, nil, &readError{err: err}
+		}
+		r.record += 1
+		if err == nil && r.header && r.written <= 0 {
+			if rejected := rejectRow(r.txt, r.record, values, nil); rejected != nil {
+				return nil, rejected, nil
+			}
+		}
+		if err == nil {
+			var ok bool
+			ok, err = r.filter.match(values)
+			if err == nil && !ok {
+				continue
+			}
+			if err == nil {
+				values, err = r.proj.project(values)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		if rejected := rejectRow(r.txt, r.record, values, err); rejected != nil {
+			rejected.Column = r.proj.inputColumn(rejected.Column)
+			return nil, rejected, nil
+		}
+		r.written += 1
+		return values, nil, nil
+	}
+}
+
+// first tells whether the row last returned is the first one written.
+func (r *rowReader) first() bool {
+	return r.written == 1
+}
+
+// send passes row to the writer. It returns false when the writer has
+// stopped.
+func (p *parsedTxt) send(row parsedRow) bool {
+	select {
+	case p.rows <- row:
+		return true
+	case <-p.stop:
+		return false
+	}
+}
+
+// PasteTxtFile writes every line of txt to the sheet through a stream writer,
+// so memory stays flat regardless of the number of rows. When types must be
+// inferred or the data range is needed up front, the input is read twice.
+// Appending to an existing sheet writes the cells in place instead.
+func (e *Excel) PasteTxtFile(txt txt.TxtFiler, sheet string, opt *CellOption, sheetOpt *SheetOption) error {
+	if e.xlFile == nil {
+		return ErrNotOpened
+	}
+	job := &PasteJob{Txt: txt, Sheet: sheet, CellOption: opt, SheetOption: sheetOpt}
+	e.markTargets([]*PasteJob{job})
+	p := newParsedTxt()
+	go parseTxtFile(job, p)
+	return e.writeParsedTxt(job, p)
+}
+
+// PasteTxtFiles writes the jobs in order like PasteTxtFile, while up to
+// workers inputs are read and converted ahead on other goroutines. The
+// workbook is only touched from the calling goroutine, so the result does not
+// depend on workers. A failed job does not stop the others, and the errors of
+// all failed jobs are returned together. done, if not nil, is called after
+// each job in order with its error, and may return an error of its own.
+func (e *Excel) PasteTxtFiles(jobs []*PasteJob, workers int, done func(job *PasteJob, err error) error) error {
+	if e.xlFile == nil {
+		return ErrNotOpened
+	}
+	e.markTargets(jobs)
+	parsed := make([]*parsedTxt, len(jobs))
+	for i := range jobs {
+		parsed[i] = newParsedTxt()
+	}
+	slots := make(chan struct{}, max(workers, 1))
+	quit := make(chan struct{})
+	defer close(quit)
+	go func() {
+		for i, job := range jobs {
+			select {
+			case slots <- struct{}{}:
+			case <-quit:
+				return
+			}
+			go parseTxtFile(job, parsed[i])
+		}
+	}()
+	errs := []error{}
+	for i, job := range jobs {
+		err := e.writeParsedTxt(job, parsed[i])
+		<-slots
+		if done != nil {
+			err = done(job, err)
+		}
+		if err != nil {
+			errs = append(errs, ErrPasteTxtFile.Details("file", job.Txt.Filename(), "sheet", job.Sheet).Wrap(err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// writeParsedTxt writes the rows of p as they come and waits for the input
+// to be closed.
+func (e *Excel) writeParsedTxt(job *PasteJob, p *parsedTxt) error {
+	defer func() {
+		close(p.stop)
+		<-p.exited
+	}()
+	sheet := job.Sheet
+	opt := job.CellOption
+	sheetOpt := job.SheetOption
+	mode := PasteModeReplace
+	if sheetOpt != nil && len(sheetOpt.Mode) > 0 {
+		mode = sheetOpt.Mode
+	}
+	exists := e.hasSheet(sheet)
+	// Another block of a sheet written in this run is never skipped.
+	if exists && mode == PasteModeSkip && !e.written[strings.ToLower(sheet)] {
+		e.log.Info("skip sheet", zap.String("sheet", sheet), zap.String("src", job.Txt.Filename()))
+		return nil
+	}
+	appending := exists && mode == PasteModeAppend
+	<-p.ready
+	if p.openErr != nil {
+		return wrapReadError(job.Txt, p.openErr)
+	}
+	profile := p.profile
+	w, err := e.newSplitWriter(job, profile, exists, appending)
+	if err != nil {
+		return err
+	}
+	skipHeader := appending && sheetOpt.SkipHeader
+	// The header row is kept for a table on a continuation sheet, even when
+	// appending leaves the first sheet as it is.
+	table := sheetOpt != nil && len(sheetOpt.TableStyle) > 0
+	typed := profile != nil && profile.Types != nil
+	for parsed := range p.rows {
+		if parsed.rejected != nil {
+			err = e.rejectRow(parsed.rejected, opt)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		first := parsed.first
+		cells := parsed.cells
+		if first && (table || (typed && opt.Header)) {
+			values := parsed.values
+			if table {
+				values = uniqueHeaders(values)
+			}
+			cells = make([]interface{}, len(values))
+			for col, value := range values {
+				cells[col] = value
+			}
+		}
+		err = w.writeRow(cells, first, first && skipHeader)
+		if err != nil {
+			return err
+		}
+	}
+	if p.err != nil {
+		return wrapReadError(job.Txt, p.err)
+	}
+	err = w.close()
+	if err != nil {
+		return err
+	}
+	if appending {
+		e.log.Info("append sheet", zap.String("sheet", sheet), zap.String("src", job.Txt.Filename()), zap.Int("row", w.top))
+	} else if w.block {
+		e.log.Info("add block", zap.String("sheet", sheet), zap.String("src", job.Txt.Filename()), zap.Int("col", w.left), zap.Int("row", w.top))
+	} else {
+		e.log.Info("add sheet", zap.String("sheet", sheet), zap.String("src", job.Txt.Filename()))
+	}
+	return nil
+}
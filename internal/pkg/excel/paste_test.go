@@ -0,0 +1,345 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kenita8/xlcmd/internal/pkg/excel/excelize"
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+	"github.com/kenita8/xlcmd/internal/pkg/file/csv"
+	"github.com/kenita8/xlcmd/internal/pkg/file/json"
+
+	"github.com/stretchr/testify/assert"
+	rawExcelize "github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+func writePasteJobs(t *testing.T, files []string, opt *CellOption, sheetOpt *SheetOption) []*PasteJob {
+	jobs := []*PasteJob{}
+	for _, pathname := range files {
+		jobs = append(jobs, &PasteJob{
+			Txt:         csv.NewCsvFile(pathname, "UTF-8"),
+			Sheet:       strings.TrimSuffix(filepath.Base(pathname), ".csv"),
+			CellOption:  opt,
+			SheetOption: sheetOpt,
+		})
+	}
+	return jobs
+}
+
+// readPackageParts returns the parts of an xlsx file. excelize writes the
+// streamed worksheets into the zip in map order, so two saves of the same
+// workbook differ only in the order of the parts.
+func readPackageParts(t *testing.T, pathname string) map[string][]byte {
+	zr, err := zip.OpenReader(pathname)
+	assert.Nil(t, err)
+	defer zr.Close()
+	parts := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		assert.Nil(t, err)
+		data, err := io.ReadAll(rc)
+		assert.Nil(t, err)
+		rc.Close()
+		parts[f.Name] = data
+	}
+	return parts
+}
+
+// sheetParts returns the names of the worksheet parts in sheet number order.
+func sheetParts(parts map[string][]byte) []string {
+	names := []string{}
+	for name := range parts {
+		if strings.HasPrefix(name, "xl/worksheets/sheet") {
+			names = append(names, name)
+		}
+	}
+	number := func(name string) int {
+		n, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "xl/worksheets/sheet"), ".xml"))
+		return n
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return number(a) - number(b)
+	})
+	return names
+}
+
+func TestPasteTxtFilesWorkers(t *testing.T) {
+	filer = &file.File{}
+	excelizer = &excelize.Excelize{}
+	files := []string{}
+	for i := 0; i < 20; i++ {
+		var sb strings.Builder
+		sb.WriteString("Time,Name,Value\n")
+		for row := 0; row < 50+i*10; row++ {
+			fmt.Fprintf(&sb, "2024-07-01 10:%02d:%02d,name%d,%d.%d\n", row/60%60, row%60, row, i*row, row%10)
+		}
+		files = append(files, writeTestFile(t, fmt.Sprintf("input%02d.csv", i), sb.String()))
+	}
+	opt := &CellOption{DecimalPlaces: -1, InferTypes: true, Header: true}
+	sheetOpt := &SheetOption{HeaderStyle: true, FreezeHeader: true, AutoFilter: true}
+	outputs := []map[string][]byte{}
+	for _, workers := range []int{1, 4, 32} {
+		output := filepath.Join(t.TempDir(), "output.xlsx")
+		e := NewExcel(zap.NewNop())
+		assert.Nil(t, e.Open(output))
+		order := []string{}
+		err := e.PasteTxtFiles(writePasteJobs(t, files, opt, sheetOpt), workers, func(job *PasteJob, err error) error {
+			order = append(order, job.Sheet)
+			return err
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, e.Save())
+		e.Close()
+		expect := []string{}
+		for _, pathname := range files {
+			expect = append(expect, strings.TrimSuffix(filepath.Base(pathname), ".csv"))
+		}
+		assert.Equal(t, expect, order)
+		outputs = append(outputs, readPackageParts(t, output))
+
+		// The sheets and the rows of each sheet are in input order.
+		f, err := rawExcelize.OpenFile(output)
+		assert.Nil(t, err)
+		assert.Equal(t, expect, f.GetSheetList())
+		for i, sheet := range expect {
+			rows, err := f.GetRows(sheet)
+			assert.Nil(t, err)
+			assert.Len(t, rows, 1+50+i*10, sheet)
+			for row := 1; row < len(rows); row++ {
+				assert.Equal(t, fmt.Sprintf("name%d", row-1), rows[row][1], "%s row %d with %d workers", sheet, row+1, workers)
+			}
+		}
+		f.Close()
+	}
+	// Every part is byte-identical to the --jobs 1 run. The files as a whole
+	// are not, as not even two runs with one worker are.
+	for i := 1; i < len(outputs); i++ {
+		assert.Equal(t, len(outputs[0]), len(outputs[i]))
+		for name, data := range outputs[0] {
+			assert.True(t, bytes.Equal(data, outputs[i][name]), "%s of run %d differs from --jobs 1", name, i)
+		}
+		// The sheets are numbered in the order they are added, so comparing
+		// the worksheet parts by number compares the sheets in order.
+		assert.Len(t, sheetParts(outputs[i]), len(files))
+		for n, name := range sheetParts(outputs[0]) {
+			assert.Equal(t, name, sheetParts(outputs[i])[n])
+			assert.True(t, bytes.Equal(outputs[0][name], outputs[i][name]), "sheet %d of run %d differs from --jobs 1", n+1, i)
+		}
+	}
+}
+
+func TestPasteTxtFilesErrors(t *testing.T) {
+	filer = &file.File{}
+	excelizer = &excelize.Excelize{}
+	good := writeTestFile(t, "good.csv", "Name,Value\na,1\n")
+	broken1 := writeTestFile(t, "broken1.csv", "Name,Value\na,1,2\n")
+	broken2 := writeTestFile(t, "broken2.csv", "Name,Value\na\n")
+	testcases := []struct {
+		files       []string
+		workers     int
+		expectFiles []string
+	}{
+		{[]string{good, good}, 2, []string{}},
+		{[]string{broken1, good, broken2}, 1, []string{broken1, broken2}},
+		{[]string{broken1, good, broken2}, 3, []string{broken1, broken2}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			defer e.Close()
+			jobs := writePasteJobs(t, tc.files, nil, nil)
+			for j := range jobs {
+				jobs[j].Sheet = "sheet" + strconv.Itoa(j)
+			}
+			err := e.PasteTxtFiles(jobs, tc.workers, nil)
+			if len(tc.expectFiles) <= 0 {
+				assert.Nil(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrPasteTxtFile))
+			assert.True(t, errors.Is(err, ErrReadInputFile))
+			for _, pathname := range tc.expectFiles {
+				assert.Contains(t, err.Error(), "file="+pathname)
+			}
+			assert.NotContains(t, err.Error(), "file="+good)
+			assert.Nil(t, e.Save())
+		})
+	}
+}
+
+// TestPasteTxtFilesJsonErrors reads broken inputs on workers at the same time.
+// Run it with -race, the readers must not share the details of an error.
+func TestPasteTxtFilesJsonErrors(t *testing.T) {
+	filer = &file.File{}
+	excelizer = &excelize.Excelize{}
+	broken1 := writeTestFile(t, "broken1.json", `[{"a":1},{"a":`)
+	broken2 := writeTestFile(t, "broken2.ndjson", "{\"b\":2}\n{\"b\" 3}\n")
+	for i := 0; i < 10; i++ {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			defer e.Close()
+			jobs := []*PasteJob{
+				{Txt: json.NewJsonFile(broken1, "UTF-8"), Sheet: "sheet1"},
+				{Txt: json.NewNdjsonFile(broken2, "UTF-8"), Sheet: "sheet2"},
+			}
+			err := e.PasteTxtFiles(jobs, 2, nil)
+			assert.True(t, errors.Is(err, json.ErrDecodeJson))
+			messages := strings.Split(err.Error(), "\n")
+			if !assert.Len(t, messages, 2) {
+				return
+			}
+			assert.Contains(t, messages[0], "file="+broken1)
+			assert.NotContains(t, messages[0], broken2)
+			assert.Contains(t, messages[1], "file="+broken2)
+			assert.NotContains(t, messages[1], broken1)
+		})
+	}
+}
+
+func TestPasteTxtFilesBlocks(t *testing.T) {
+	filer = &file.File{}
+	excelizer = &excelize.Excelize{}
+	first := writeTestFile(t, "first.csv", "a,b\n1,2\n3,4\n")
+	second := writeTestFile(t, "second.csv", "c,d,e\n5,6,7\n")
+	testcases := []struct {
+		sheetOpts    []*SheetOption
+		expectRows   [][]string
+		expectTables []string
+		expectFilter string
+		expectFreeze string
+	}{
+		{
+			sheetOpts: []*SheetOption{
+				{StartCell: "B2", AutoFilter: true, FreezeHeader: true},
+			},
+			expectRows:   [][]string{nil, {"", "a", "b"}, {"", "1", "2"}, {"", "3", "4"}},
+			expectTables: []string{},
+			expectFilter: "'report'!$B$2:$C$4",
+			expectFreeze: "A3",
+		},
+		{
+			sheetOpts: []*SheetOption{
+				{TableStyle: "TableStyleMedium2", InPlace: true},
+				{StartCell: "D3", TableStyle: "TableStyleMedium2", InPlace: true},
+			},
+			expectRows:   [][]string{{"a", "b"}, {"1", "2"}, {"3", "4", "", "c", "d", "e"}, {"", "", "", "5", "6", "7"}},
+			expectTables: []string{"A1:B3", "D3:F4"},
+		},
+		{
+			sheetOpts: []*SheetOption{
+				{StartCell: "C1", AutoFilter: true, InPlace: true},
+				{StartCell: "A5", AutoFilter: true, InPlace: true, Mode: PasteModeSkip},
+			},
+			expectRows:   [][]string{{"", "", "a", "b"}, {"", "", "1", "2"}, {"", "", "3", "4"}, nil, {"c", "d", "e"}, {"5", "6", "7"}},
+			expectTables: []string{},
+			expectFilter: "'report'!$C$1:$D$3",
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			opt := &CellOption{InferTypes: true, Header: true}
+			jobs := []*PasteJob{}
+			for j, sheetOpt := range tc.sheetOpts {
+				input := []string{first, second}[j]
+				jobs = append(jobs, &PasteJob{Txt: csv.NewCsvFile(input, "UTF-8"), Sheet: "report", CellOption: opt, SheetOption: sheetOpt})
+			}
+			assert.Nil(t, e.PasteTxtFiles(jobs, 2, nil))
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			rows, err := f.GetRows("report")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectRows, rows)
+			tables, err := f.GetTables("report")
+			assert.Nil(t, err)
+			ranges := []string{}
+			for _, table := range tables {
+				ranges = append(ranges, table.Range)
+			}
+			assert.Equal(t, tc.expectTables, ranges)
+			filter := ""
+			for _, name := range f.GetDefinedName() {
+				if name.Scope == "report" {
+					filter = name.RefersTo
+				}
+			}
+			assert.Equal(t, tc.expectFilter, filter)
+			panes, err := f.GetPanes("report")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectFreeze, panes.TopLeftCell)
+		})
+	}
+}
+
+// TestPasteTxtFilesBlocksReplace writes the same blocks of a sheet twice, the
+// second time with shorter inputs, which must replace those of the first run.
+func TestPasteTxtFilesBlocksReplace(t *testing.T) {
+	filer = &file.File{}
+	excelizer = &excelize.Excelize{}
+	runs := [][]string{
+		{
+			writeTestFile(t, "first1.csv", "a,b\n1,2\n3,4\n5,6\n7,8\n"),
+			writeTestFile(t, "second1.csv", "c,d\n5,6\n7,8\n"),
+		},
+		{
+			writeTestFile(t, "first2.csv", "a,b\n1,2\n"),
+			writeTestFile(t, "second2.csv", "c,d\n5,6\n"),
+		},
+	}
+	sheetOpts := []*SheetOption{
+		{InPlace: true, Mode: PasteModeReplace},
+		{StartCell: "D1", InPlace: true, Mode: PasteModeReplace},
+	}
+	output := filepath.Join(t.TempDir(), "output.xlsx")
+	for _, inputs := range runs {
+		e := NewExcel(zap.NewNop())
+		assert.Nil(t, e.Open(output))
+		opt := &CellOption{InferTypes: true, Header: true}
+		jobs := []*PasteJob{}
+		for j, input := range inputs {
+			jobs = append(jobs, &PasteJob{Txt: csv.NewCsvFile(input, "UTF-8"), Sheet: "report", CellOption: opt, SheetOption: sheetOpts[j]})
+		}
+		assert.Nil(t, e.PasteTxtFiles(jobs, 2, nil))
+		assert.Nil(t, e.Save())
+		e.Close()
+	}
+
+	f, err := rawExcelize.OpenFile(output)
+	assert.Nil(t, err)
+	defer f.Close()
+	rows, err := f.GetRows("report")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"a", "b", "", "c", "d"}, {"1", "2", "", "5", "6"}}, rows)
+}
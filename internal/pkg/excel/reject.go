@@ -0,0 +1,90 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"errors"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/kenita8/xlcmd/internal/pkg/file/txt"
+	rawExcelize "github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	reasonCellTooLong = "cell text is longer than 32767 characters"
+)
+
+var (
+	// RejectedHeader names the values of RejectedRow.Values.
+	RejectedHeader = []string{"file", "line", "column", "reason"}
+)
+
+// RejectedRow is a row left out of a sheet. Column is the 1-based column of
+// the rejected cell, or 0 when the whole record could not be read.
+type RejectedRow struct {
+	File   string
+	Line   int
+	Column int
+	Reason string
+}
+
+func (r *RejectedRow) Values() []string {
+	column := ""
+	if r.Column > 0 {
+		column = strconv.Itoa(r.Column)
+	}
+	return []string{r.File, strconv.Itoa(r.Line), column, r.Reason}
+}
+
+// Rejected returns the rows left out so far in input order.
+func (e *Excel) Rejected() []RejectedRow {
+	return e.rejected
+}
+
+func onError(opt *CellOption) ErrorPolicy {
+	if opt == nil || len(opt.OnError) <= 0 {
+		return OnErrorFail
+	}
+	return opt.OnError
+}
+
+// rejectRow returns why the record-th record must be left out, or nil.
+func rejectRow(txtFile txt.TxtFiler, record int, values []string, err error) *RejectedRow {
+	var rowErr *txt.RowError
+	if errors.As(err, &rowErr) {
+		return &RejectedRow{File: txtFile.Filename(), Line: rowErr.Line, Reason: rowErr.Error()}
+	}
+	for col, value := range values {
+		if len(value) > rawExcelize.TotalCellChars && utf8.RuneCountInString(value) > rawExcelize.TotalCellChars {
+			line := record
+			if numberer, ok := txtFile.(txt.LineNumberer); ok {
+				line = numberer.LineNumber()
+			}
+			return &RejectedRow{File: txtFile.Filename(), Line: line, Column: col + 1, Reason: reasonCellTooLong}
+		}
+	}
+	return nil
+}
+
+// rejectRow leaves the row out, or fails when opt does not allow bad rows.
+func (e *Excel) rejectRow(rejected *RejectedRow, opt *CellOption) error {
+	if onError(opt) == OnErrorFail {
+		return ErrRejectedRow.Details("file", rejected.File, "line", rejected.Line, "column", rejected.Column, "reason", rejected.Reason)
+	}
+	e.log.Warn("skip row", zap.String("src", rejected.File), zap.Int("line", rejected.Line), zap.Int("column", rejected.Column), zap.String("reason", rejected.Reason))
+	e.rejected = append(e.rejected, *rejected)
+	return nil
+}
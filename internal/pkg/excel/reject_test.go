@@ -0,0 +1,152 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kenita8/xlcmd/internal/pkg/excel/excelize"
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+	"github.com/kenita8/xlcmd/internal/pkg/file/csv"
+
+	"github.com/stretchr/testify/assert"
+	rawExcelize "github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+func TestPasteTxtFileOnError(t *testing.T) {
+	long := strings.Repeat("x", rawExcelize.TotalCellChars+1)
+	input := writeTestFile(t, "input.csv", "Name,Value\na,1\nb,2,3\n\"c\nd\",4\ne,"+long+"\nf,\"5\"6\"\ng,7\n")
+	testcases := []struct {
+		opt            *CellOption
+		expectRows     [][]string
+		expectRejected []RejectedRow
+		expectErr      error
+	}{
+		{
+			opt:       &CellOption{},
+			expectErr: ErrReadInputFile,
+		},
+		{
+			opt:        &CellOption{OnError: OnErrorSkip},
+			expectRows: [][]string{{"Name", "Value"}, {"a", "1"}, {"c\nd", "4"}, {"g", "7"}},
+			expectRejected: []RejectedRow{
+				{File: input, Line: 3, Reason: "record on line 3: wrong number of fields"},
+				{File: input, Line: 6, Column: 2, Reason: reasonCellTooLong},
+				{File: input, Line: 7, Reason: "parse error on line 7, column 5: extraneous or missing \" in quoted-field"},
+			},
+		},
+		{
+			opt:        &CellOption{OnError: OnErrorReport, InferTypes: true, Header: true},
+			expectRows: [][]string{{"Name", "Value"}, {"a", "1"}, {"c\nd", "4"}, {"g", "7"}},
+			expectRejected: []RejectedRow{
+				{File: input, Line: 3, Reason: "record on line 3: wrong number of fields"},
+				{File: input, Line: 6, Column: 2, Reason: reasonCellTooLong},
+				{File: input, Line: 7, Reason: "parse error on line 7, column 5: extraneous or missing \" in quoted-field"},
+			},
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			err := e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "data", tc.opt, nil)
+			if tc.expectErr != nil {
+				assert.True(t, errors.Is(err, tc.expectErr))
+				e.Close()
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectRejected, e.Rejected())
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			rows, err := f.GetRows("data")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectRows, rows)
+		})
+	}
+}
+
+func TestPasteTxtFileRejectFail(t *testing.T) {
+	filer = &file.File{}
+	excelizer = &excelize.Excelize{}
+	input := writeTestFile(t, "input.csv", "Name,Value\na,"+strings.Repeat("x", rawExcelize.TotalCellChars+1)+"\n")
+	e := NewExcel(zap.NewNop())
+	assert.Nil(t, e.Open(filepath.Join(t.TempDir(), "output.xlsx")))
+	defer e.Close()
+	err := e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "data", nil, nil)
+	assert.True(t, errors.Is(err, ErrRejectedRow))
+	assert.Contains(t, err.Error(), "line=2, column=2")
+	assert.Empty(t, e.Rejected())
+}
+
+func TestPasteTxtFileRejectFirstRecord(t *testing.T) {
+	long := strings.Repeat("x", rawExcelize.TotalCellChars+1)
+	columns, err := ParseColumns("Value,Name")
+	assert.Nil(t, err)
+	where, err := ParseWhere(`col("Value") > 1`)
+	assert.Nil(t, err)
+	testcases := []struct {
+		data           string
+		expectRejected []RejectedRow
+	}{
+		{"a\"b,c\nName,Value\nx,1\ny,2\n", []RejectedRow{{Line: 1, Reason: "parse error on line 1, column 2: bare \" in non-quoted-field"}}},
+		{long + ",c\nName,Value\nx,1\ny,2\n", []RejectedRow{{Line: 1, Column: 1, Reason: reasonCellTooLong}}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			input := writeTestFile(t, "input.csv", tc.data)
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			opt := &CellOption{OnError: OnErrorSkip, Header: true, InferTypes: true, Columns: columns, Where: where}
+			assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "data", opt, &SheetOption{TableStyle: "TableStyleMedium2"}))
+			for j := range tc.expectRejected {
+				tc.expectRejected[j].File = input
+			}
+			assert.Equal(t, tc.expectRejected, e.Rejected())
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			rows, err := f.GetRows("data")
+			assert.Nil(t, err)
+			assert.Equal(t, [][]string{{"Value", "Name"}, {"2", "y"}}, rows)
+			// The types are inferred from the data rows only.
+			cellType, err := f.GetCellType("data", "A2")
+			assert.Nil(t, err)
+			assert.Equal(t, rawExcelize.CellTypeUnset, cellType)
+			tables, err := f.GetTables("data")
+			assert.Nil(t, err)
+			if assert.Len(t, tables, 1) {
+				assert.Equal(t, "A1:B2", tables[0].Range)
+			}
+		})
+	}
+}
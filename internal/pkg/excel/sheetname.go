@@ -0,0 +1,95 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	MaxSheetNameLength = 31
+	defaultSheetName   = "Sheet"
+	// Excel reserves this name for the change history of shared workbooks.
+	reservedSheetName = "History"
+)
+
+var (
+	sheetNameReplacer = strings.NewReplacer(
+		"[", "_", "]", "_", ":", "_", "*", "_", "?", "_", "/", "_", `\`, "_",
+	)
+)
+
+// SanitizeSheetName turns name into a name Excel accepts. Invalid characters
+// are replaced with "_" and the result is cut to MaxSheetNameLength.
+func SanitizeSheetName(name string) string {
+	name = sheetNameReplacer.Replace(name)
+	if strings.HasPrefix(name, "'") {
+		name = "_" + name[1:]
+	}
+	name = truncateSheetName(name, MaxSheetNameLength)
+	if strings.HasSuffix(name, "'") {
+		name = name[:len(name)-1] + "_"
+	}
+	if len(strings.TrimSpace(name)) <= 0 {
+		return defaultSheetName
+	}
+	if strings.EqualFold(name, reservedSheetName) {
+		return name + "_"
+	}
+	return name
+}
+
+func truncateSheetName(name string, length int) string {
+	runes := []rune(name)
+	if len(runes) <= length {
+		return name
+	}
+	return string(runes[:length])
+}
+
+// SheetNamer hands out sanitized sheet names that are unique within a
+// workbook. Sheet names are compared case-insensitively like Excel does.
+type SheetNamer struct {
+	used map[string]bool
+}
+
+// NewSheetNamer returns a namer that never hands out the reserved names, which
+// are kept for sheets the caller writes itself.
+func NewSheetNamer(reserved ...string) *SheetNamer {
+	n := &SheetNamer{used: map[string]bool{}}
+	for _, name := range reserved {
+		n.used[strings.ToLower(name)] = true
+	}
+	return n
+}
+
+// Name returns the sanitized name, adding " (2)", " (3)", ... when it is
+// already taken. The base name is shortened so the suffix always fits.
+func (n *SheetNamer) Name(name string) string {
+	name = SanitizeSheetName(name)
+	unique := name
+	for i := 2; n.used[strings.ToLower(unique)]; i++ {
+		unique = ContinuationSheetName(name, i)
+	}
+	n.used[strings.ToLower(unique)] = true
+	return unique
+}
+
+// ContinuationSheetName returns the name of the n-th sheet of a series, e.g.
+// "cpu.tsv (2)".
+func ContinuationSheetName(name string, n int) string {
+	suffix := " (" + strconv.Itoa(n) + ")"
+	return truncateSheetName(name, MaxSheetNameLength-len(suffix)) + suffix
+}
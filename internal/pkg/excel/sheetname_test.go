@@ -0,0 +1,68 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeSheetName(t *testing.T) {
+	testcases := []struct {
+		name   string
+		expect string
+	}{
+		{"cpu.tsv", "cpu.tsv"},
+		{"a[1]:b*c?d/e\\f", "a_1__b_c_d_e_f"},
+		{"'quoted'", "_quoted_"},
+		{"0123456789012345678901234567890123456789", "0123456789012345678901234567890"},
+		{"あいうえおかきくけこさしすせそたちつてとなにぬねのはひふへほまみむめも", "あいうえおかきくけこさしすせそたちつてとなにぬねのはひふへほま"},
+		{"", "Sheet"},
+		{"history", "history_"},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expect, SanitizeSheetName(tc.name))
+		})
+	}
+}
+
+func TestSheetNamer(t *testing.T) {
+	testcases := []struct {
+		names    []string
+		reserved []string
+		expect   []string
+	}{
+		{[]string{"data.csv", "DATA.csv", "data.csv"}, nil, []string{"data.csv", "DATA.csv (2)", "data.csv (3)"}},
+		{[]string{"a:b", "a/b"}, nil, []string{"a_b", "a_b (2)"}},
+		{
+			[]string{"0123456789012345678901234567890123", "0123456789012345678901234567890"},
+			nil,
+			[]string{"0123456789012345678901234567890", "012345678901234567890123456 (2)"},
+		},
+		{[]string{"Counters", "unparsed", "data"}, []string{"counters", "unparsed"}, []string{"Counters (2)", "unparsed (2)", "data"}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			namer := NewSheetNamer(tc.reserved...)
+			names := []string{}
+			for _, name := range tc.names {
+				names = append(names, namer.Name(name))
+			}
+			assert.Equal(t, tc.expect, names)
+		})
+	}
+}
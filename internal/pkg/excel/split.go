@@ -0,0 +1,487 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	rawExcelize "github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+// SplitPolicy decides where an input goes on when it does not fit in a sheet.
+type SplitPolicy string
+
+const (
+	// SplitSheets continues on sheets named like "cpu.tsv (2)".
+	SplitSheets SplitPolicy = "sheets"
+	// SplitWorkbooks continues on a sheet of the same name in workbooks
+	// named like "output (2).xlsx".
+	SplitWorkbooks SplitPolicy = "workbooks"
+)
+
+var (
+	// maxRows and maxCols are the limits of a sheet.
+	maxRows = rawExcelize.TotalRows
+	maxCols = rawExcelize.MaxColumns
+)
+
+// sheetPart is a sheet, or a block of columns of one, an input is written to.
+type sheetPart struct {
+	e        *Excel
+	sheet    string
+	sw       rowWriter
+	sheetOpt *SheetOption
+	styles   []int
+	header   int
+	left     int
+	top      int
+	bottom   int
+	cols     int
+}
+
+// splitWriter writes the rows of an input, going on with new parts at A1.
+type splitWriter struct {
+	e       *Excel
+	job     *PasteJob
+	profile *TxtProfile
+	// parts are the parts of the current rows, one per block of columns.
+	parts []*sheetPart
+	// count is the number of parts so far, the first sheet included.
+	count     int
+	continued bool
+	// left and top are the cell the input starts at on the first sheet.
+	left int
+	top  int
+	// line is the next row of the current parts, counted from 1 at the top
+	// of the first part.
+	line int
+	// records is the number of input rows written, and partRecords the
+	// number written before the current parts.
+	records     int
+	partRecords int
+	header      []interface{}
+	exists      bool
+	appending   bool
+	// block is set when the sheet was written before in this run, so the
+	// input is another block of it.
+	block bool
+}
+
+func (e *Excel) newSplitWriter(job *PasteJob, profile *TxtProfile, exists bool, appending bool) (*splitWriter, error) {
+	w := &splitWriter{
+		e:         e,
+		job:       job,
+		profile:   profile,
+		count:     1,
+		line:      1,
+		left:      1,
+		top:       1,
+		exists:    exists,
+		appending: appending,
+		block:     e.written[strings.ToLower(job.Sheet)],
+	}
+	if job.SheetOption != nil && len(job.SheetOption.StartCell) > 0 {
+		col, row, err := excelizer.CellNameToCoordinates(job.SheetOption.StartCell)
+		if err != nil {
+			return nil, ErrConvertCellName.Details("cell", job.SheetOption.StartCell).Wrap(err)
+		}
+		w.left, w.top = col, row
+	}
+	if appending {
+		last, err := e.LastRow(job.Sheet)
+		if err != nil {
+			return nil, err
+		}
+		w.top = last + 1
+	}
+	return w, nil
+}
+
+func (w *splitWriter) split() SplitPolicy {
+	if w.job.SheetOption == nil || len(w.job.SheetOption.Split) <= 0 {
+		return SplitSheets
+	}
+	return w.job.SheetOption.Split
+}
+
+// inPlace tells whether the first sheet is written cell by cell, keeping
+// what is already there.
+func (w *splitWriter) inPlace() bool {
+	if w.exists && (w.left > 1 || w.top > 1) {
+		return true
+	}
+	return w.appending || w.block || (w.job.SheetOption != nil && w.job.SheetOption.InPlace)
+}
+
+// setTop is the row the current parts start at on the sheet of their first
+// block of columns.
+func (w *splitWriter) setTop() int {
+	if w.continued {
+		return 1
+	}
+	return w.top
+}
+
+// chunk returns the c-th block of columns of cells. The first block is
+// narrower when the input does not start in column A.
+func (w *splitWriter) chunk(cells []interface{}, c int) []interface{} {
+	cols := w.chunkRange(c)
+	return cells[min(cols[0], len(cells)):min(cols[1], len(cells))]
+}
+
+// chunks is the number of blocks of columns of a row of n cells.
+func (w *splitWriter) chunks(n int) int {
+	first := maxCols - w.left + 1
+	if n <= first {
+		return 1
+	}
+	return 1 + (n-first+maxCols-1)/maxCols
+}
+
+// hasHeader tells whether the first row of the input is a header, which the
+// parts to come start with. A table needs a header row even without one.
+func (w *splitWriter) hasHeader() bool {
+	opt := w.job.CellOption
+	sheetOpt := w.job.SheetOption
+	return (opt != nil && opt.Header) || (sheetOpt != nil && len(sheetOpt.TableStyle) > 0)
+}
+
+// writeRow writes the cells of an input row. header marks the first row,
+// which is kept for the parts to come when it is a header.
+func (w *splitWriter) writeRow(cells []interface{}, header bool, skip bool) error {
+	if header && w.hasHeader() {
+		w.header = cells
+	}
+	if skip {
+		return nil
+	}
+	if w.setTop()+w.line-1 > maxRows {
+		err := w.closeParts()
+		if err != nil {
+			return err
+		}
+		w.continued = true
+		w.partRecords = w.records
+		w.line = 1
+		if w.header != nil {
+			w.line = 2
+		}
+	}
+	for c := 0; c < w.chunks(len(cells)); c++ {
+		part, err := w.part(c)
+		if err != nil {
+			return err
+		}
+		err = part.setRow(w.line, w.chunk(cells, c), header)
+		if err != nil {
+			return err
+		}
+	}
+	w.records += 1
+	w.line += 1
+	return nil
+}
+
+// setRow writes cells to the line-th row of the part.
+func (p *sheetPart) setRow(line int, cells []interface{}, header bool) error {
+	row := p.top + line - 1
+	cell, err := excelizer.CoordinatesToCellName(p.left, row)
+	if err != nil {
+		return ErrConvertCellName.Details("col", p.left, "row", row).Wrap(err)
+	}
+	cells, err = p.e.cellStyles(cells, p.styles)
+	if err != nil {
+		return err
+	}
+	if header && p.header > 0 {
+		for col, value := range cells {
+			if c, ok := value.(rawExcelize.Cell); ok {
+				c.StyleID = p.header
+				cells[col] = c
+			} else {
+				cells[col] = rawExcelize.Cell{StyleID: p.header, Value: value}
+			}
+		}
+	}
+	err = p.sw.SetRow(cell, cells)
+	if err != nil {
+		return ErrSetCellValue.Details("sheet", p.sheet, "row", row).Wrap(err)
+	}
+	p.cols = max(p.cols, len(cells))
+	p.bottom = row
+	return nil
+}
+
+// part returns the part of the c-th block of columns of the current rows,
+// opening it when it is not there yet.
+func (w *splitWriter) part(c int) (*sheetPart, error) {
+	for len(w.parts) <= c {
+		w.parts = append(w.parts, nil)
+	}
+	if w.parts[c] != nil {
+		return w.parts[c], nil
+	}
+	var part *sheetPart
+	var err error
+	if c == 0 && !w.continued {
+		part, err = w.openFirst()
+	} else {
+		w.count += 1
+		part, err = w.openNext(c, w.count)
+	}
+	if err != nil {
+		return nil, err
+	}
+	w.parts[c] = part
+	return part, nil
+}
+
+// openFirst opens the sheet the input is written to when it fits.
+func (w *splitWriter) openFirst() (*sheetPart, error) {
+	e := w.e
+	sheet := w.job.Sheet
+	sheetOpt := w.job.SheetOption
+	part := &sheetPart{e: e, sheet: sheet, left: w.left, top: w.top}
+	var err error
+	if w.exists && !w.appending && !w.block {
+		err = e.clearTables(sheet)
+		if err != nil {
+			return nil, err
+		}
+		// A stream writer starts the sheet from scratch, while in place the
+		// rows of the last run would be left below shorter input.
+		if w.inPlace() {
+			err = e.clearCells(sheet, w.left, w.top)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if w.appending {
+		// Appending keeps the formatting the sheet already has, and the
+		// tables and the auto filter are extended when the parts are closed.
+	} else if w.block {
+		// The freeze panes and the auto filter of the sheet are those of its
+		// first block.
+		part.sheetOpt = sheetOpt
+	} else {
+		err = e.prepareSheet(sheet, w.left, w.top, w.partProfile(0), sheetOpt)
+		if err != nil {
+			return nil, err
+		}
+		part.sheetOpt = sheetOpt
+	}
+	if w.inPlace() {
+		part.sw = &cellWriter{xlFile: e.xlFile, sheet: sheet}
+	} else {
+		part.sw, err = e.xlFile.NewStreamWriter(sheet)
+		if err != nil {
+			return nil, ErrNewStreamWriter.Details("sheet", sheet).Wrap(err)
+		}
+	}
+	err = part.format(w.profile, w.chunkRange(0))
+	if err != nil {
+		return nil, err
+	}
+	e.markWritten(sheet)
+	return part, nil
+}
+
+func (e *Excel) markWritten(sheet string) {
+	if e.written == nil {
+		e.written = map[string]bool{}
+	}
+	e.written[strings.ToLower(sheet)] = true
+}
+
+func (e *Excel) markTargets(jobs []*PasteJob) {
+	if e.targets == nil {
+		e.targets = map[string]bool{}
+	}
+	for _, job := range jobs {
+		e.targets[strings.ToLower(job.Sheet)] = true
+	}
+}
+
+// openNext opens the n-th part, which holds the c-th block of columns, and
+// writes the header row to it, if any.
+func (w *splitWriter) openNext(c int, n int) (*sheetPart, error) {
+	e := w.e
+	sheet := w.job.Sheet
+	var err error
+	if w.split() == SplitWorkbooks {
+		e, err = w.e.spillWorkbook(n)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		sheet = ContinuationSheetName(sheet, n)
+		// Another input went, or goes, to a sheet of that name.
+		for e.written[strings.ToLower(sheet)] || e.targets[strings.ToLower(sheet)] {
+			w.count += 1
+			sheet = ContinuationSheetName(w.job.Sheet, w.count)
+		}
+	}
+	sheetOpt := w.job.SheetOption
+	if e.hasSheet(sheet) {
+		err = e.clearTables(sheet)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = e.prepareSheet(sheet, 1, 1, w.partProfile(c), sheetOpt)
+	if err != nil {
+		return nil, err
+	}
+	part := &sheetPart{e: e, sheet: sheet, sheetOpt: sheetOpt, left: 1, top: 1}
+	part.sw, err = e.xlFile.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, ErrNewStreamWriter.Details("sheet", sheet).Wrap(err)
+	}
+	err = part.format(w.profile, w.chunkRange(c))
+	if err != nil {
+		return nil, err
+	}
+	if w.header != nil && w.line > 1 {
+		err = part.setRow(1, w.chunk(w.header, c), true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	e.markWritten(sheet)
+	e.log.Info("continue sheet", zap.String("sheet", sheet), zap.String("src", w.job.Txt.Filename()), zap.Int("part", n))
+	return part, nil
+}
+
+// chunkRange returns the input columns of the c-th block of columns.
+func (w *splitWriter) chunkRange(c int) [2]int {
+	first := maxCols - w.left + 1
+	if c == 0 {
+		return [2]int{0, first}
+	}
+	return [2]int{first + (c-1)*maxCols, first + c*maxCols}
+}
+
+// format makes the styles of the part in its own workbook. cols are the input
+// columns of the part.
+func (p *sheetPart) format(profile *TxtProfile, cols [2]int) error {
+	if profile != nil {
+		styles, err := p.e.columnStyles(profile.Types)
+		if err != nil {
+			return err
+		}
+		p.styles = styles[min(cols[0], len(styles)):min(cols[1], len(styles))]
+	}
+	if p.sheetOpt != nil && p.sheetOpt.HeaderStyle {
+		style, err := p.e.headerStyle()
+		if err != nil {
+			return err
+		}
+		p.header = style
+	}
+	if p.sheetOpt != nil && p.sheetOpt.AutoFit && profile != nil {
+		return p.fitColumns(profile, cols)
+	}
+	return nil
+}
+
+// partProfile is the size of the c-th block of columns of the current rows,
+// so that the auto filter covers only the part.
+func (w *splitWriter) partProfile(c int) *TxtProfile {
+	if w.profile == nil {
+		return nil
+	}
+	rows := w.profile.Rows - w.partRecords
+	if w.job.SheetOption != nil && w.appending && w.job.SheetOption.SkipHeader {
+		rows -= 1
+	}
+	if w.continued && w.header != nil {
+		rows += 1
+	}
+	cols := w.chunkRange(c)
+	return &TxtProfile{
+		Rows: min(rows, maxRows-w.setTop()+1),
+		Cols: max(min(w.profile.Cols, cols[1])-cols[0], 0),
+	}
+}
+
+func (w *splitWriter) closeParts() error {
+	for _, part := range w.parts {
+		if part == nil {
+			continue
+		}
+		err := part.close()
+		if err != nil {
+			return err
+		}
+	}
+	// The tables and the auto filter of a sheet appended to go on over the
+	// rows written below them.
+	if w.appending && !w.continued && len(w.parts) > 0 && w.parts[0] != nil && w.parts[0].bottom >= w.top {
+		err := w.e.extendRanges(w.job.Sheet, w.top-1, w.parts[0].bottom)
+		if err != nil {
+			return err
+		}
+	}
+	w.parts = nil
+	return nil
+}
+
+func (p *sheetPart) close() error {
+	if p.sheetOpt != nil && len(p.sheetOpt.TableStyle) > 0 && p.bottom >= p.top {
+		err := p.e.addTable(p.sw, p.sheet, p.left, p.top, p.cols, p.bottom, p.sheetOpt.TableStyle)
+		if err != nil {
+			return err
+		}
+	}
+	err := p.sw.Flush()
+	if err != nil {
+		return ErrFlushSheet.Details("sheet", p.sheet).Wrap(err)
+	}
+	return nil
+}
+
+// close finishes the parts. An input with no rows still gets its sheet.
+func (w *splitWriter) close() error {
+	if w.count == 1 && !w.continued && len(w.parts) <= 0 {
+		_, err := w.part(0)
+		if err != nil {
+			return err
+		}
+	}
+	return w.closeParts()
+}
+
+// spillWorkbook returns the n-th workbook of the output, opening the ones
+// up to it.
+func (e *Excel) spillWorkbook(n int) (*Excel, error) {
+	for len(e.spills) < n-1 {
+		spill := NewExcel(e.log)
+		err := spill.Open(spillPathname(e.pathname, len(e.spills)+2))
+		if err != nil {
+			return nil, err
+		}
+		e.spills = append(e.spills, spill)
+	}
+	return e.spills[n-2], nil
+}
+
+// spillPathname returns the name of the n-th workbook, e.g. "output (2).xlsx".
+func spillPathname(pathname string, n int) string {
+	ext := filepath.Ext(pathname)
+	return strings.TrimSuffix(pathname, ext) + " (" + strconv.Itoa(n) + ")" + ext
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kenita8/xlcmd/internal/pkg/excel/excelize"
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+	"github.com/kenita8/xlcmd/internal/pkg/file/csv"
+
+	"github.com/stretchr/testify/assert"
+	rawExcelize "github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+func TestPasteTxtFileSplit(t *testing.T) {
+	defer func(rows int, cols int) {
+		maxRows, maxCols = rows, cols
+	}(maxRows, maxCols)
+	maxRows, maxCols = 4, 3
+	var sb strings.Builder
+	sb.WriteString("a,b,c,d,e\n")
+	for i := 1; i <= 7; i++ {
+		fmt.Fprintf(&sb, "%d,%d,%d,%d,%d\n", i, i*10, i*100, i*1000, i*10000)
+	}
+	input := writeTestFile(t, "input.csv", sb.String())
+	narrow := writeTestFile(t, "narrow.csv", "a,b\n1,2\n3,4\n")
+	testcases := []struct {
+		input        string
+		opt          *CellOption
+		sheetOpt     *SheetOption
+		expectSheets map[string][][]string
+		expectTables map[string][]string
+	}{
+		{
+			input:    input,
+			sheetOpt: &SheetOption{TableStyle: "TableStyleMedium2"},
+			expectSheets: map[string][][]string{
+				"data":     {{"a", "b", "c"}, {"1", "10", "100"}, {"2", "20", "200"}, {"3", "30", "300"}},
+				"data (2)": {{"d", "e"}, {"1000", "10000"}, {"2000", "20000"}, {"3000", "30000"}},
+				"data (3)": {{"a", "b", "c"}, {"4", "40", "400"}, {"5", "50", "500"}, {"6", "60", "600"}},
+				"data (4)": {{"d", "e"}, {"4000", "40000"}, {"5000", "50000"}, {"6000", "60000"}},
+				"data (5)": {{"a", "b", "c"}, {"7", "70", "700"}},
+				"data (6)": {{"d", "e"}, {"7000", "70000"}},
+			},
+			expectTables: map[string][]string{
+				"data": {"A1:C4"}, "data (2)": {"A1:B4"}, "data (5)": {"A1:C2"}, "data (6)": {"A1:B2"},
+			},
+		},
+		{
+			input:    input,
+			sheetOpt: &SheetOption{TableStyle: "TableStyleMedium2", StartCell: "B3"},
+			expectSheets: map[string][][]string{
+				"data":     {nil, nil, {"", "a", "b"}, {"", "1", "10"}},
+				"data (2)": {{"c", "d", "e"}, {"100", "1000", "10000"}},
+				"data (3)": {{"a", "b"}, {"2", "20"}, {"3", "30"}, {"4", "40"}},
+				"data (4)": {{"c", "d", "e"}, {"200", "2000", "20000"}, {"300", "3000", "30000"}, {"400", "4000", "40000"}},
+				"data (5)": {{"a", "b"}, {"5", "50"}, {"6", "60"}, {"7", "70"}},
+				"data (6)": {{"c", "d", "e"}, {"500", "5000", "50000"}, {"600", "6000", "60000"}, {"700", "7000", "70000"}},
+			},
+			expectTables: map[string][]string{
+				"data": {"B3:C4"}, "data (2)": {"A1:C2"}, "data (3)": {"A1:B4"}, "data (6)": {"A1:C4"},
+			},
+		},
+		{
+			input:    narrow,
+			sheetOpt: &SheetOption{TableStyle: "TableStyleMedium2"},
+			expectSheets: map[string][][]string{
+				"data": {{"a", "b"}, {"1", "2"}, {"3", "4"}},
+			},
+			expectTables: map[string][]string{"data": {"A1:B3"}},
+		},
+		{
+			input: input,
+			opt:   &CellOption{Header: true},
+			expectSheets: map[string][][]string{
+				"data":     {{"a", "b", "c"}, {"1", "10", "100"}, {"2", "20", "200"}, {"3", "30", "300"}},
+				"data (2)": {{"d", "e"}, {"1000", "10000"}, {"2000", "20000"}, {"3000", "30000"}},
+				"data (3)": {{"a", "b", "c"}, {"4", "40", "400"}, {"5", "50", "500"}, {"6", "60", "600"}},
+				"data (4)": {{"d", "e"}, {"4000", "40000"}, {"5000", "50000"}, {"6000", "60000"}},
+				"data (5)": {{"a", "b", "c"}, {"7", "70", "700"}},
+				"data (6)": {{"d", "e"}, {"7000", "70000"}},
+			},
+		},
+		// the first row is not repeated when it is not a header
+		{
+			input: input,
+			opt:   &CellOption{Header: false},
+			expectSheets: map[string][][]string{
+				"data":     {{"a", "b", "c"}, {"1", "10", "100"}, {"2", "20", "200"}, {"3", "30", "300"}},
+				"data (2)": {{"d", "e"}, {"1000", "10000"}, {"2000", "20000"}, {"3000", "30000"}},
+				"data (3)": {{"4", "40", "400"}, {"5", "50", "500"}, {"6", "60", "600"}, {"7", "70", "700"}},
+				"data (4)": {{"4000", "40000"}, {"5000", "50000"}, {"6000", "60000"}, {"7000", "70000"}},
+			},
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(tc.input, "UTF-8"), "data", tc.opt, tc.sheetOpt))
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			assert.Equal(t, len(tc.expectSheets), len(f.GetSheetList()))
+			for sheet, expect := range tc.expectSheets {
+				rows, err := f.GetRows(sheet)
+				assert.Nil(t, err)
+				assert.Equal(t, expect, rows, sheet)
+			}
+			for sheet, expect := range tc.expectTables {
+				tables, err := f.GetTables(sheet)
+				assert.Nil(t, err)
+				ranges := []string{}
+				for _, table := range tables {
+					ranges = append(ranges, table.Range)
+				}
+				assert.Equal(t, expect, ranges, sheet)
+			}
+		})
+	}
+}
+
+func TestPasteTxtFileSplitWorkbooks(t *testing.T) {
+	defer func(rows int) {
+		maxRows = rows
+	}(maxRows)
+	maxRows = 3
+	filer = &file.File{}
+	excelizer = &excelize.Excelize{}
+	input := writeTestFile(t, "input.csv", "a,b\n1,2\n3,4\n5,6\n")
+	dir := t.TempDir()
+	e := NewExcel(zap.NewNop())
+	assert.Nil(t, e.Open(filepath.Join(dir, "output.xlsx")))
+	opt := &CellOption{InferTypes: true, Header: true}
+	sheetOpt := &SheetOption{AutoFilter: true, Split: SplitWorkbooks}
+	assert.Nil(t, e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "data", opt, sheetOpt))
+	assert.Nil(t, e.Save())
+	e.Close()
+
+	expects := map[string][][]string{
+		"output.xlsx":     {{"a", "b"}, {"1", "2"}, {"3", "4"}},
+		"output (2).xlsx": {{"a", "b"}, {"5", "6"}},
+	}
+	for name, expect := range expects {
+		f, err := rawExcelize.OpenFile(filepath.Join(dir, name))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"data"}, f.GetSheetList())
+		rows, err := f.GetRows("data")
+		assert.Nil(t, err)
+		assert.Equal(t, expect, rows, name)
+		f.Close()
+	}
+}
+
+func TestPasteTxtFilesSplitTargets(t *testing.T) {
+	defer func(rows int) {
+		maxRows = rows
+	}(maxRows)
+	maxRows = 3
+	filer = &file.File{}
+	excelizer = &excelize.Excelize{}
+	long := writeTestFile(t, "long.csv", "a,b\n1,2\n3,4\n5,6\n")
+	short := writeTestFile(t, "short.csv", "x\n9\n")
+	output := filepath.Join(t.TempDir(), "output.xlsx")
+	e := NewExcel(zap.NewNop())
+	assert.Nil(t, e.Open(output))
+	opt := &CellOption{InferTypes: true, Header: true}
+	jobs := []*PasteJob{
+		{Txt: csv.NewCsvFile(long, "UTF-8"), Sheet: "data", CellOption: opt, SheetOption: &SheetOption{}},
+		{Txt: csv.NewCsvFile(short, "UTF-8"), Sheet: "data (2)", CellOption: opt, SheetOption: &SheetOption{}},
+	}
+	assert.Nil(t, e.PasteTxtFiles(jobs, 1, nil))
+	assert.Nil(t, e.Save())
+	e.Close()
+
+	f, err := rawExcelize.OpenFile(output)
+	assert.Nil(t, err)
+	defer f.Close()
+	expects := map[string][][]string{
+		"data":     {{"a", "b"}, {"1", "2"}, {"3", "4"}},
+		"data (2)": {{"x"}, {"9"}},
+		"data (3)": {{"a", "b"}, {"5", "6"}},
+	}
+	for sheet, expect := range expects {
+		rows, err := f.GetRows(sheet)
+		assert.Nil(t, err)
+		assert.Equal(t, expect, rows, sheet)
+	}
+}
@@ -0,0 +1,543 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Where is a condition a row must meet to be written, such as
+// `col("% Processor Time") > 80 && col(1) >= "07/15/2024"`.
+//
+// col(n) is the value of the 1-based column n of the input, before Columns
+// picks the columns, and col("name") the value of the column with that
+// header. Values are compared with ==, !=, <, <=, > and >= to other columns,
+// numbers or strings: as numbers when one side is a number, as dates when
+// both sides are dates, as numbers when both sides are numbers, and as
+// strings otherwise. A value that is not a number is only unequal to a
+// number. =~ and !~ match a value to a regular expression string. Conditions
+// are combined with &&, || and !, and grouped with parentheses. Strings are
+// double quoted with Go escapes, or back quoted as is.
+type Where struct {
+	root whereExpr
+	// names are the headers of the columns given by name.
+	names []string
+}
+
+type whereExpr interface {
+	eval(f *rowFilter, row []string) bool
+}
+
+type whereAnd struct {
+	left, right whereExpr
+}
+
+func (w *whereAnd) eval(f *rowFilter, row []string) bool {
+	return w.left.eval(f, row) && w.right.eval(f, row)
+}
+
+type whereOr struct {
+	left, right whereExpr
+}
+
+func (w *whereOr) eval(f *rowFilter, row []string) bool {
+	return w.left.eval(f, row) || w.right.eval(f, row)
+}
+
+type whereNot struct {
+	expr whereExpr
+}
+
+func (w *whereNot) eval(f *rowFilter, row []string) bool {
+	return !w.expr.eval(f, row)
+}
+
+// whereOperand is a column or a literal. col is the 0-based column, or -1
+// for a literal, and slot the index of the column in Where.names, or -1.
+type whereOperand struct {
+	col     int
+	slot    int
+	literal string
+	number  bool
+}
+
+type whereCompare struct {
+	op          string
+	left, right *whereOperand
+}
+
+func (w *whereCompare) eval(f *rowFilter, row []string) bool {
+	a := f.value(w.left, row)
+	b := f.value(w.right, row)
+	var c int
+	switch {
+	case w.left.number || w.right.number:
+		if !a.isNumber || !b.isNumber {
+			return w.op == "!="
+		}
+		c = cmp.Compare(a.number, b.number)
+	case a.isTime && b.isTime:
+		c = a.time.Compare(b.time)
+	case a.isNumber && b.isNumber:
+		c = cmp.Compare(a.number, b.number)
+	default:
+		c = strings.Compare(a.text, b.text)
+	}
+	switch w.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+type whereMatch struct {
+	left   *whereOperand
+	re     *regexp.Regexp
+	negate bool
+}
+
+func (w *whereMatch) eval(f *rowFilter, row []string) bool {
+	return w.re.MatchString(f.text(w.left, row)) != w.negate
+}
+
+// whereError is a syntax error at the 1-based character pos of a condition.
+type whereError struct {
+	pos    int
+	reason string
+}
+
+func (w *whereError) Error() string {
+	return w.reason
+}
+
+// ParseWhere reads a condition, reporting where it cannot be read.
+func ParseWhere(text string) (*Where, error) {
+	p := &whereParser{text: text}
+	w := &Where{}
+	p.where = w
+	err := p.next()
+	if err == nil {
+		w.root, err = p.parseOr()
+	}
+	if err == nil && p.tok.kind != tokenEnd {
+		err = p.errorf("unexpected " + p.tok.describe())
+	}
+	if err != nil {
+		we := err.(*whereError)
+		return nil, ErrInvalidWhere.Details("where", text, "pos", we.pos, "reason", we.reason)
+	}
+	return w, nil
+}
+
+// ByHeader tells whether the condition needs the header row to find columns
+// by name.
+func (w *Where) ByHeader() bool {
+	return len(w.names) > 0
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOp
+)
+
+type whereToken struct {
+	kind tokenKind
+	// text is the identifier, the number, the unquoted string or the operator.
+	text string
+	pos  int
+}
+
+func (t *whereToken) describe() string {
+	switch t.kind {
+	case tokenEnd:
+		return "end of condition"
+	case tokenString:
+		return "string " + strconv.Quote(t.text)
+	}
+	return strconv.Quote(t.text)
+}
+
+var (
+	whereOps = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "(", ")"}
+)
+
+type whereParser struct {
+	text  string
+	off   int
+	tok   whereToken
+	where *Where
+}
+
+func (p *whereParser) errorf(reason string) error {
+	return &whereError{pos: p.tok.pos, reason: reason}
+}
+
+// next reads the next token into p.tok.
+func (p *whereParser) next() error {
+	for p.off < len(p.text) {
+		r, size := utf8.DecodeRuneInString(p.text[p.off:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		p.off += size
+	}
+	start := p.off
+	p.tok = whereToken{pos: utf8.RuneCountInString(p.text[:start]) + 1}
+	if start >= len(p.text) {
+		p.tok.kind = tokenEnd
+		return nil
+	}
+	rest := p.text[start:]
+	c := rest[0]
+	switch {
+	case c == '"' || c == '`':
+		end := closingQuote(rest)
+		if end < 0 {
+			return p.errorf("string is not closed")
+		}
+		text, err := strconv.Unquote(rest[:end+1])
+		if err != nil {
+			return p.errorf("invalid escape in string")
+		}
+		p.tok.kind = tokenString
+		p.tok.text = text
+		p.off += end + 1
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		end := 1
+		for end < len(rest) && (strings.IndexByte("0123456789.eE", rest[end]) >= 0 ||
+			((rest[end] == '+' || rest[end] == '-') && (rest[end-1] == 'e' || rest[end-1] == 'E'))) {
+			end++
+		}
+		if _, err := strconv.ParseFloat(rest[:end], 64); err != nil {
+			return p.errorf("invalid number " + strconv.Quote(rest[:end]))
+		}
+		p.tok.kind = tokenNumber
+		p.tok.text = rest[:end]
+		p.off += end
+	case c == '_' || unicode.IsLetter(rune(c)):
+		end := strings.IndexFunc(rest, func(r rune) bool {
+			return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if end < 0 {
+			end = len(rest)
+		}
+		p.tok.kind = tokenIdent
+		p.tok.text = rest[:end]
+		p.off += end
+	default:
+		for _, op := range whereOps {
+			if strings.HasPrefix(rest, op) {
+				p.tok.kind = tokenOp
+				p.tok.text = op
+				p.off += len(op)
+				return nil
+			}
+		}
+		r, _ := utf8.DecodeRuneInString(rest)
+		return p.errorf("unexpected character " + strconv.QuoteRune(r))
+	}
+	return nil
+}
+
+// closingQuote returns the index of the quote closing the string at the
+// start of s, or -1. Only a double quoted string has escapes.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '\\' && s[0] == '"' {
+			i++
+		} else if s[i] == s[0] {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *whereParser) isOp(op string) bool {
+	return p.tok.kind == tokenOp && p.tok.text == op
+}
+
+func (p *whereParser) expect(op string) error {
+	if !p.isOp(op) {
+		return p.errorf("expected " + strconv.Quote(op) + " but found " + p.tok.describe())
+	}
+	return p.next()
+}
+
+func (p *whereParser) parseOr() (whereExpr, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.isOp("||") {
+		err = p.next()
+		if err != nil {
+			return nil, err
+		}
+		var right whereExpr
+		right, err = p.parseAnd()
+		left = &whereOr{left: left, right: right}
+	}
+	return left, err
+}
+
+func (p *whereParser) parseAnd() (whereExpr, error) {
+	left, err := p.parseNot()
+	for err == nil && p.isOp("&&") {
+		err = p.next()
+		if err != nil {
+			return nil, err
+		}
+		var right whereExpr
+		right, err = p.parseNot()
+		left = &whereAnd{left: left, right: right}
+	}
+	return left, err
+}
+
+func (p *whereParser) parseNot() (whereExpr, error) {
+	if !p.isOp("!") {
+		return p.parseCondition()
+	}
+	err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	expr, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return &whereNot{expr: expr}, nil
+}
+
+func (p *whereParser) parseCondition() (whereExpr, error) {
+	if p.isOp("(") {
+		err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(")")
+	}
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenOp || !slices.Contains([]string{"==", "!=", "<", "<=", ">", ">=", "=~", "!~"}, p.tok.text) {
+		return nil, p.errorf("expected a comparison operator but found " + p.tok.describe())
+	}
+	op := p.tok.text
+	err = p.next()
+	if err != nil {
+		return nil, err
+	}
+	if op == "=~" || op == "!~" {
+		if p.tok.kind != tokenString {
+			return nil, p.errorf("expected a regular expression string but found " + p.tok.describe())
+		}
+		re, err := regexp.Compile(p.tok.text)
+		if err != nil {
+			return nil, p.errorf(err.Error())
+		}
+		return &whereMatch{left: left, re: re, negate: op == "!~"}, p.next()
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return &whereCompare{op: op, left: left, right: right}, nil
+}
+
+func (p *whereParser) parseOperand() (*whereOperand, error) {
+	operand := &whereOperand{col: -1, slot: -1, literal: p.tok.text}
+	switch {
+	case p.tok.kind == tokenNumber:
+		operand.number = true
+		return operand, p.next()
+	case p.tok.kind == tokenString:
+		return operand, p.next()
+	case p.tok.kind != tokenIdent || p.tok.text != "col":
+		return nil, p.errorf("expected col(...), a number or a string but found " + p.tok.describe())
+	}
+	err := p.next()
+	if err == nil {
+		err = p.expect("(")
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch p.tok.kind {
+	case tokenNumber:
+		col, err := strconv.Atoi(p.tok.text)
+		if err != nil || col < 1 {
+			return nil, p.errorf("column index must be 1 or more")
+		}
+		operand.col = col - 1
+	case tokenString:
+		operand.slot = slices.Index(p.where.names, p.tok.text)
+		if operand.slot < 0 {
+			operand.slot = len(p.where.names)
+			p.where.names = append(p.where.names, p.tok.text)
+		}
+	default:
+		return nil, p.errorf("expected a column index or header but found " + p.tok.describe())
+	}
+	err = p.next()
+	if err != nil {
+		return nil, err
+	}
+	return operand, p.expect(")")
+}
+
+// whereValue is a value read as a number and as a date where it is one.
+type whereValue struct {
+	text     string
+	number   float64
+	isNumber bool
+	time     time.Time
+	isTime   bool
+}
+
+// rowFilter is a Where evaluated over one pass of an input.
+type rowFilter struct {
+	where    *Where
+	header   bool
+	layouts  []string
+	locale   *NumberLocale
+	cols     []int
+	literals map[*whereOperand]whereValue
+}
+
+func newRowFilter(opt *CellOption) *rowFilter {
+	if opt == nil || opt.Where == nil {
+		return nil
+	}
+	layouts := DefaultDateLayouts
+	if len(opt.DateLayouts) > 0 {
+		layouts = opt.DateLayouts
+	}
+	return &rowFilter{
+		where:    opt.Where,
+		header:   opt.Header,
+		layouts:  layouts,
+		locale:   opt.Number,
+		literals: map[*whereOperand]whereValue{},
+	}
+}
+
+// match tells whether the row is written.
+func (f *rowFilter) match(values []string) (bool, error) {
+	if f == nil {
+		return true, nil
+	}
+	if f.cols == nil {
+		f.cols = make([]int, len(f.where.names))
+		for i, name := range f.where.names {
+			f.cols[i] = -1
+			if f.header {
+				f.cols[i] = slices.Index(values, name)
+			}
+			if f.cols[i] < 0 {
+				return false, &columnError{column: name}
+			}
+		}
+		if f.header {
+			return true, nil
+		}
+	}
+	return f.where.root.eval(f, values), nil
+}
+
+func (f *rowFilter) text(o *whereOperand, row []string) string {
+	col := o.col
+	if o.slot >= 0 {
+		col = f.cols[o.slot]
+	}
+	if col < 0 {
+		return o.literal
+	}
+	if col < len(row) {
+		return row[col]
+	}
+	return ""
+}
+
+func (f *rowFilter) value(o *whereOperand, row []string) whereValue {
+	if o.col < 0 && o.slot < 0 {
+		v, ok := f.literals[o]
+		if !ok {
+			if o.number {
+				// Numbers in the condition are not written the way of the
+				// input.
+				v = whereValue{text: o.literal}
+				v.number, v.isNumber = parseNumber(o.literal)
+			} else {
+				v = f.newValue(o.literal)
+			}
+			f.literals[o] = v
+		}
+		return v
+	}
+	return f.newValue(f.text(o, row))
+}
+
+func (f *rowFilter) newValue(text string) whereValue {
+	v := whereValue{text: text}
+	trimmed := strings.TrimSpace(text)
+	if len(trimmed) <= 0 {
+		return v
+	}
+	if f.locale != nil {
+		if n, ok := parseLocaleNumber(trimmed, f.locale); ok {
+			v.number, v.isNumber = parseNumber(n.text)
+		}
+	} else if isFloat(trimmed) {
+		v.number, v.isNumber = parseNumber(trimmed)
+	}
+	if v.isNumber {
+		return v
+	}
+	for _, layout := range f.layouts {
+		t, err := time.Parse(layout, trimmed)
+		if err == nil {
+			v.time, v.isTime = t, true
+			break
+		}
+	}
+	return v
+}
+
+func parseNumber(text string) (float64, bool) {
+	n, err := strconv.ParseFloat(text, 64)
+	return n, err == nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package excel
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/kenita8/xlcmd/internal/pkg/excel/excelize"
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+	"github.com/kenita8/xlcmd/internal/pkg/file/csv"
+
+	"github.com/stretchr/testify/assert"
+	rawExcelize "github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+func TestParseWhere(t *testing.T) {
+	testcases := []struct {
+		text      string
+		expectErr string
+	}{
+		{`col("% Processor Time") > 80 && col(1) >= "07/15/2024"`, ""},
+		{`!(col(2) == "a" || col(3) != -1.5e3) && col("x") =~ "^a\\d" && col(4) !~ ` + "`[0-9]+`", ""},
+		{`col(2) > `, "where condition is invalid(where=col(2) > , pos=10, reason=expected col(...), a number or a string but found end of condition)"},
+		{`col(2) 80`, "where condition is invalid(where=col(2) 80, pos=8, reason=expected a comparison operator but found \"80\")"},
+		{`(col(2) > 80`, "where condition is invalid(where=(col(2) > 80, pos=13, reason=expected \")\" but found end of condition)"},
+		{`col(-1) > 80`, "where condition is invalid(where=col(-1) > 80, pos=5, reason=column index must be 1 or more)"},
+		{`col(0) > 80`, "where condition is invalid(where=col(0) > 80, pos=5, reason=column index must be 1 or more)"},
+		{`col(2) > "abc`, "where condition is invalid(where=col(2) > \"abc, pos=10, reason=string is not closed)"},
+		{`col(2) =~ 1`, "where condition is invalid(where=col(2) =~ 1, pos=11, reason=expected a regular expression string but found \"1\")"},
+		{`col(2) = 1`, "where condition is invalid(where=col(2) = 1, pos=8, reason=unexpected character '=')"},
+		{`col(2) > 1 col(3)`, "where condition is invalid(where=col(2) > 1 col(3), pos=12, reason=unexpected \"col\")"},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := ParseWhere(tc.text)
+			if len(tc.expectErr) > 0 {
+				assert.True(t, errors.Is(err, ErrInvalidWhere))
+				assert.Equal(t, tc.expectErr, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestRowFilter(t *testing.T) {
+	header := []string{"Time", "% Processor Time", "Name"}
+	testcases := []struct {
+		where  string
+		opt    *CellOption
+		row    []string
+		expect bool
+	}{
+		{`col("% Processor Time") > 80`, nil, []string{"", "80.5", ""}, true},
+		{`col("% Processor Time") > 80`, nil, []string{"", "9", ""}, false},
+		{`col("% Processor Time") > 80`, nil, []string{"", "", ""}, false},
+		{`col("% Processor Time") != 80`, nil, []string{"", "n/a", ""}, true},
+		{`col(1) >= "07/15/2024"`, nil, []string{"07/15/2024 14:11:18.978", "", ""}, true},
+		{`col(1) >= "07/15/2024"`, nil, []string{"07/14/2024 23:59:59", "", ""}, false},
+		{`col(3) < "b"`, nil, []string{"", "", "abc"}, true},
+		{`col(3) == "10"`, nil, []string{"", "", "10.0"}, true},
+		{`col(3) =~ "^ab" && !(col(2) > 1)`, nil, []string{"", "0", "abc"}, true},
+		{`col(2) >= 1000`, &CellOption{Number: &NumberLocale{Decimal: ',', Thousands: "."}}, []string{"", "1.234,5", ""}, true},
+		{`col(6) == ""`, nil, []string{"", "", ""}, true},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			where, err := ParseWhere(tc.where)
+			assert.Nil(t, err)
+			opt := &CellOption{Header: true}
+			if tc.opt != nil {
+				opt = tc.opt
+				opt.Header = true
+			}
+			opt.Where = where
+			f := newRowFilter(opt)
+			ok, err := f.match(header)
+			assert.Nil(t, err)
+			assert.True(t, ok)
+			ok, err = f.match(tc.row)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expect, ok)
+		})
+	}
+}
+
+func TestPasteTxtFileWhere(t *testing.T) {
+	input := writeTestFile(t, "input.csv", "Time,CPU,Name\n"+
+		"07/14/2024 23:59:00,95,a\n"+
+		"07/15/2024 00:00:00,90,b\n"+
+		"07/15/2024 00:01:00,10,c\n"+
+		"07/15/2024 00:02:00,85,d\n")
+	testcases := []struct {
+		where      string
+		columns    string
+		expectRows [][]string
+		expectErr  error
+	}{
+		{
+			where:      `col("CPU") > 80 && col(1) >= "07/15/2024"`,
+			expectRows: [][]string{{"Time", "CPU", "Name"}, {"2024-07-15 00:00:00", "90", "b"}, {"2024-07-15 00:02:00", "85", "d"}},
+		},
+		{
+			where:      `col("CPU") < 50`,
+			columns:    "Name",
+			expectRows: [][]string{{"Name"}, {"c"}},
+		},
+		{
+			where:      `col(3) == "d"`,
+			columns:    "3,1",
+			expectRows: [][]string{{"Name", "Time"}, {"d", "2024-07-15 00:02:00"}},
+		},
+		{
+			where:     `col("Memory") < 50`,
+			expectErr: ErrColumnNotFound,
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			filer = &file.File{}
+			excelizer = &excelize.Excelize{}
+			where, err := ParseWhere(tc.where)
+			assert.Nil(t, err)
+			opt := &CellOption{DecimalPlaces: -1, InferTypes: true, Header: true, Where: where}
+			if len(tc.columns) > 0 {
+				opt.Columns, err = ParseColumns(tc.columns)
+				assert.Nil(t, err)
+			}
+			output := filepath.Join(t.TempDir(), "output.xlsx")
+			e := NewExcel(zap.NewNop())
+			assert.Nil(t, e.Open(output))
+			err = e.PasteTxtFile(csv.NewCsvFile(input, "UTF-8"), "data", opt, &SheetOption{AutoFilter: true})
+			if tc.expectErr != nil {
+				assert.True(t, errors.Is(err, tc.expectErr))
+				e.Close()
+				return
+			}
+			assert.Nil(t, err)
+			assert.Nil(t, e.Save())
+			e.Close()
+
+			f, err := rawExcelize.OpenFile(output)
+			assert.Nil(t, err)
+			defer f.Close()
+			rows, err := f.GetRows("data")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectRows, rows)
+		})
+	}
+}
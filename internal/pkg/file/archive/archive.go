@@ -0,0 +1,198 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Separator joins an archive path and a member name, e.g. "logs.zip!/a.csv".
+	Separator = "!/"
+)
+
+type kind int
+
+const (
+	kindNone kind = iota
+	kindGzip
+	kindZip
+	kindTarGz
+)
+
+func kindOf(pathname string) kind {
+	name := strings.ToLower(pathname)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"):
+		return kindTarGz
+	case strings.HasSuffix(name, ".gz"):
+		return kindGzip
+	case strings.HasSuffix(name, ".zip"):
+		return kindZip
+	}
+	return kindNone
+}
+
+// IsArchive reports whether pathname is a .gz, .zip, .tar.gz or .tgz file.
+func IsArchive(pathname string) bool {
+	return kindOf(pathname) != kindNone
+}
+
+// Join returns the path of a member as shown to users.
+func Join(archive string, member string) string {
+	return archive + Separator + member
+}
+
+// Members lists the regular files of an archive with slash-separated names.
+// A .gz file has one member named after the file without ".gz".
+func Members(archive string) ([]string, error) {
+	switch kindOf(archive) {
+	case kindGzip:
+		return []string{gzipMember(archive)}, nil
+	case kindZip:
+		r, err := zip.OpenReader(archive)
+		if err != nil {
+			return nil, ErrReadArchive.Details("path", archive).Wrap(err)
+		}
+		defer r.Close()
+		members := []string{}
+		for _, f := range r.File {
+			if f.Mode().IsRegular() {
+				members = append(members, f.Name)
+			}
+		}
+		return members, nil
+	case kindTarGz:
+		members := []string{}
+		err := walkTar(archive, func(header *tar.Header, r io.Reader) (bool, error) {
+			if header.Typeflag == tar.TypeReg {
+				members = append(members, strings.TrimPrefix(header.Name, "./"))
+			}
+			return false, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return members, nil
+	}
+	return nil, ErrNotArchive.Details("path", archive)
+}
+
+func gzipMember(archive string) string {
+	return strings.TrimSuffix(filepath.Base(archive), filepath.Ext(archive))
+}
+
+func walkTar(archive string, fn func(header *tar.Header, r io.Reader) (bool, error)) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return ErrReadArchive.Details("path", archive).Wrap(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return ErrReadArchive.Details("path", archive).Wrap(err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return ErrReadArchive.Details("path", archive).Wrap(err)
+		}
+		done, err := fn(header, tr)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// Extract copies a member to a new file in dir and returns its path. The file
+// keeps the base name of the member, so its extension tells the format.
+// The caller removes the file.
+func Extract(archive string, member string, dir string) (string, error) {
+	out, err := os.CreateTemp(dir, "*-"+path.Base(member))
+	if err != nil {
+		return "", ErrExtractMember.Details("path", Join(archive, member)).Wrap(err)
+	}
+	defer out.Close()
+	err = copyMember(out, archive, member)
+	if err != nil {
+		os.Remove(out.Name())
+		return "", ErrExtractMember.Details("path", Join(archive, member)).Wrap(err)
+	}
+	return out.Name(), nil
+}
+
+func copyMember(w io.Writer, archive string, member string) error {
+	switch kindOf(archive) {
+	case kindGzip:
+		if member != gzipMember(archive) {
+			break
+		}
+		f, err := os.Open(archive)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		_, err = io.Copy(w, gz)
+		return err
+	case kindZip:
+		r, err := zip.OpenReader(archive)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if f.Name != member {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			_, err = io.Copy(w, rc)
+			return err
+		}
+	case kindTarGz:
+		found := false
+		err := walkTar(archive, func(header *tar.Header, r io.Reader) (bool, error) {
+			if header.Typeflag != tar.TypeReg || strings.TrimPrefix(header.Name, "./") != member {
+				return false, nil
+			}
+			found = true
+			_, err := io.Copy(w, r)
+			return true, err
+		})
+		if err != nil || found {
+			return err
+		}
+	}
+	return ErrNotFoundMember.Details("member", member)
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testMembers = map[string]string{
+	"a.csv":     "a,1\n",
+	"sub/b.tsv": "b\t2\n",
+}
+
+func writeZip(t *testing.T, pathname string) {
+	f, err := os.Create(pathname)
+	assert.Nil(t, err)
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	_, err = zw.Create("sub/")
+	assert.Nil(t, err)
+	for _, name := range []string{"a.csv", "sub/b.tsv"} {
+		w, err := zw.Create(name)
+		assert.Nil(t, err)
+		w.Write([]byte(testMembers[name]))
+	}
+	assert.Nil(t, zw.Close())
+}
+
+func writeTarGz(t *testing.T, pathname string) {
+	f, err := os.Create(pathname)
+	assert.Nil(t, err)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	assert.Nil(t, tw.WriteHeader(&tar.Header{Name: "./sub/", Typeflag: tar.TypeDir, Mode: 0755}))
+	for _, name := range []string{"a.csv", "sub/b.tsv"} {
+		data := testMembers[name]
+		assert.Nil(t, tw.WriteHeader(&tar.Header{Name: "./" + name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(data))}))
+		tw.Write([]byte(data))
+	}
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gz.Close())
+}
+
+func writeGz(t *testing.T, pathname string) {
+	f, err := os.Create(pathname)
+	assert.Nil(t, err)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(testMembers["a.csv"]))
+	assert.Nil(t, gz.Close())
+}
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	testcases := []struct {
+		archive       string
+		write         func(t *testing.T, pathname string)
+		expectMembers []string
+	}{
+		{"data.zip", writeZip, []string{"a.csv", "sub/b.tsv"}},
+		{"data.tar.gz", writeTarGz, []string{"a.csv", "sub/b.tsv"}},
+		{"a.csv.gz", writeGz, []string{"a.csv"}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			archive := filepath.Join(dir, tc.archive)
+			tc.write(t, archive)
+			assert.True(t, IsArchive(archive))
+			members, err := Members(archive)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectMembers, members)
+			for _, member := range members {
+				extracted, err := Extract(archive, member, dir)
+				assert.Nil(t, err)
+				assert.Equal(t, filepath.Ext(member), filepath.Ext(extracted))
+				data, err := os.ReadFile(extracted)
+				assert.Nil(t, err)
+				assert.Equal(t, testMembers[member], string(data))
+				os.Remove(extracted)
+			}
+			_, err = Extract(archive, "missing.csv", dir)
+			assert.ErrorIs(t, err, ErrExtractMember)
+		})
+	}
+}
+
+func TestIsArchive(t *testing.T) {
+	testcases := []struct {
+		pathname string
+		expect   bool
+	}{
+		{"a.csv", false},
+		{"a.CSV.GZ", true},
+		{"a.tgz", true},
+		{"a.zip", true},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expect, IsArchive(tc.pathname))
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package file
+
+import (
+	"bytes"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+const (
+	AutoEncodingName = "auto"
+	sniffLen         = 512
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// Auto decodes UTF-8 and UTF-16 input without knowing the encoding in advance.
+// A leading BOM selects the encoding and is stripped. Without a BOM, input
+// that looks like UTF-16LE (as written by perfmon) is decoded as such, and
+// anything else is read as UTF-8.
+var Auto encoding.Encoding = autoEncoding{}
+
+type autoEncoding struct{}
+
+func (a autoEncoding) NewDecoder() *encoding.Decoder {
+	return &encoding.Decoder{Transformer: &autoDecoder{}}
+}
+
+func (a autoEncoding) NewEncoder() *encoding.Encoder {
+	return unicode.UTF8.NewEncoder()
+}
+
+func (a autoEncoding) String() string {
+	return AutoEncodingName
+}
+
+type autoDecoder struct {
+	decoder transform.Transformer
+}
+
+func (d *autoDecoder) Transform(dst, src []byte, atEOF bool) (int, int, error) {
+	if d.decoder == nil {
+		if len(src) < sniffLen && !atEOF {
+			return 0, 0, transform.ErrShortSrc
+		}
+		d.decoder = DetectEncoding(src).NewDecoder()
+	}
+	return d.decoder.Transform(dst, src, atEOF)
+}
+
+func (d *autoDecoder) Reset() {
+	d.decoder = nil
+}
+
+// DetectEncoding guesses the encoding from the first bytes of the input.
+func DetectEncoding(head []byte) encoding.Encoding {
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		return unicode.UTF8BOM
+	case bytes.HasPrefix(head, bomUTF16LE):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case bytes.HasPrefix(head, bomUTF16BE):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	case looksUTF16LE(head):
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	}
+	return unicode.UTF8
+}
+
+// looksUTF16LE reports whether most high bytes of the 16-bit units are NUL,
+// which is the case for UTF-16LE text that is mostly ASCII.
+func looksUTF16LE(head []byte) bool {
+	units := len(head) / 2
+	if units == 0 {
+		return false
+	}
+	odd := 0
+	even := 0
+	for i := 0; i+1 < len(head); i += 2 {
+		if head[i] == 0 {
+			even++
+		}
+		if head[i+1] == 0 {
+			odd++
+		}
+	}
+	return even == 0 && odd*2 > units
+}
@@ -14,7 +14,11 @@
 package csv
 
 import (
+	"bufio"
 	rawCsv "encoding/csv"
+	"errors"
+	"io"
+	"strings"
 
 	"github.com/kenita8/xlcmd/internal/pkg/file"
 	"github.com/kenita8/xlcmd/internal/pkg/file/txt"
@@ -27,7 +31,21 @@ var (
 type CsvFile struct {
 	txt.TxtFile
 	csvReader *rawCsv.Reader
+	line      int
 	Comma     rune
+	// Sniff guesses Comma from the first lines instead.
+	Sniff bool
+	// Comment starts a line that is ignored. Zero means no comment lines.
+	Comment    rune
+	LazyQuotes bool
+	// TrimSpace removes leading and trailing white space from every field.
+	TrimSpace bool
+	// FieldsPerRecord works like encoding/csv: zero takes the number of
+	// fields of the first record and a negative number allows any.
+	FieldsPerRecord int
+	// SkipRows lines are dropped before parsing, e.g. a preamble that is not
+	// CSV.
+	SkipRows int
 }
 
 func NewCsvFile(pathname string, encoding string) *CsvFile {
@@ -44,11 +62,54 @@ func NewCsvFile(pathname string, encoding string) *CsvFile {
 }
 
 func (c *CsvFile) OpenReadModeInternal() error {
-	c.csvReader = NewReader(c.Fp)
-	c.csvReader.Comma = c.Comma
+	var r io.Reader = c.Rc
+	comma := c.Comma
+	if c.SkipRows > 0 || c.Sniff {
+		br := bufio.NewReaderSize(c.Rc, sniffSize)
+		for i := 0; i < c.SkipRows; i++ {
+			_, err := br.ReadSlice('\n')
+			for err == bufio.ErrBufferFull {
+				_, err = br.ReadSlice('\n')
+			}
+			if err != nil {
+				break
+			}
+		}
+		if c.Sniff {
+			sample, _ := br.Peek(sniffSize)
+			comma = Sniff(sample, c.Comment)
+		}
+		r = br
+	}
+	c.csvReader = NewReader(r)
+	c.csvReader.Comma = comma
+	c.csvReader.Comment = c.Comment
+	c.csvReader.LazyQuotes = c.LazyQuotes
+	c.csvReader.TrimLeadingSpace = c.TrimSpace
+	c.csvReader.FieldsPerRecord = c.FieldsPerRecord
 	return nil
 }
 
+// ReadOneLine returns a malformed record as a txt.RowError, after which the
+// next record can be read.
 func (c *CsvFile) ReadOneLine() ([]string, error) {
-	return c.csvReader.Read()
+	values, err := c.csvReader.Read()
+	if err != nil {
+		var parseErr *rawCsv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &txt.RowError{Line: parseErr.StartLine + c.SkipRows, Err: err}
+		}
+		return nil, err
+	}
+	c.line, _ = c.csvReader.FieldPos(0)
+	if c.TrimSpace {
+		for i, value := range values {
+			values[i] = strings.TrimSpace(value)
+		}
+	}
+	return values, nil
+}
+
+func (c *CsvFile) LineNumber() int {
+	return c.line + c.SkipRows
 }
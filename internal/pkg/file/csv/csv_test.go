@@ -45,7 +45,7 @@ func TestReadOneLine(t *testing.T) {
 			file := "file1"
 			encoding := "UTF-8"
 			tx := NewCsvFile(file, encoding)
-			tx.Fp = mReadWriteCloser
+			tx.Rc = mReadWriteCloser
 			tx.OpenReadModeInternal()
 			actualData, actualErr := tx.ReadOneLine()
 			if tc.expectErr != nil {
@@ -56,3 +56,111 @@ func TestReadOneLine(t *testing.T) {
 		})
 	}
 }
+
+func TestReadEncoding(t *testing.T) {
+	testcases := []struct {
+		pathname   string
+		encoding   string
+		expectData [][]string
+	}{
+		{"testdata/shift_jis.csv", "Shift_JIS", [][]string{{"日付", "気温", "天気"}, {"2024-01-01", "10.0", "晴れ"}}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tx := NewCsvFile(tc.pathname, tc.encoding)
+			err := tx.OpenReadMode()
+			assert.Nil(t, err)
+			defer tx.Close()
+			actualData := [][]string{}
+			for {
+				values, err := tx.ReadOneLine()
+				if err == io.EOF {
+					break
+				}
+				assert.Nil(t, err)
+				actualData = append(actualData, values)
+			}
+			assert.Equal(t, tc.expectData, actualData)
+		})
+	}
+}
+
+func TestSniff(t *testing.T) {
+	testcases := []struct {
+		sample  string
+		comment rune
+		expect  rune
+	}{
+		{"a,b,c\n1,2,3\n", 0, ','},
+		{"a;b;c\n1,5;2,5;3\n", 0, ';'},
+		{"a\tb\n1\t2\n", 0, '\t'},
+		{"a|b|c\n1|2|3", 0, '|'},
+		{"# generated, 2024\na;b\n1;2\n", '#', ';'},
+		{"\"x;y\",b\n\"1;2\",3\n", 0, ','},
+		{"name\nabc\n", 0, ','},
+		{"", 0, ','},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expect, Sniff([]byte(tc.sample), tc.comment))
+		})
+	}
+}
+
+func TestReadDialect(t *testing.T) {
+	testcases := []struct {
+		pathname   string
+		setup      func(c *CsvFile)
+		expectData [][]string
+		expectErr  bool
+	}{
+		{
+			pathname:   "testdata/dialect.csv",
+			setup:      func(c *CsvFile) { c.Sniff = true; c.Comment = '#'; c.SkipRows = 1 },
+			expectData: [][]string{{"date", "value", "note"}, {"2024-07-01", " 1,5 ", "ok"}, {"2024-07-02", "2,5", "say \"hi\""}},
+		},
+		{
+			pathname:   "testdata/dialect.csv",
+			setup:      func(c *CsvFile) { c.Comma = ';'; c.Comment = '#'; c.SkipRows = 1; c.TrimSpace = true },
+			expectData: [][]string{{"date", "value", "note"}, {"2024-07-01", "1,5", "ok"}, {"2024-07-02", "2,5", "say \"hi\""}},
+		},
+		{
+			pathname:  "testdata/dialect.csv",
+			setup:     func(c *CsvFile) { c.Comma = ';'; c.Comment = '#' },
+			expectErr: true,
+		},
+		{
+			pathname:   "testdata/lazy.csv",
+			setup:      func(c *CsvFile) { c.LazyQuotes = true; c.FieldsPerRecord = -1 },
+			expectData: [][]string{{"a", "b"}, {"1", "2 \"inch\"", "x"}},
+		},
+		{
+			pathname:  "testdata/lazy.csv",
+			setup:     func(c *CsvFile) {},
+			expectErr: true,
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tx := NewCsvFile(tc.pathname, "UTF-8")
+			tc.setup(tx)
+			err := tx.OpenReadMode()
+			assert.Nil(t, err)
+			defer tx.Close()
+			actualData := [][]string{}
+			for {
+				values, err := tx.ReadOneLine()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					assert.True(t, tc.expectErr, err.Error())
+					return
+				}
+				actualData = append(actualData, values)
+			}
+			assert.False(t, tc.expectErr)
+			assert.Equal(t, tc.expectData, actualData)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package csv
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// sniffSize is the number of bytes looked at to guess the delimiter.
+	sniffSize = 64 * 1024
+	// sniffLines is the number of lines looked at to guess the delimiter.
+	sniffLines = 20
+)
+
+var (
+	// SniffDelimiters are the delimiters Sniff chooses from, in order of
+	// preference when the lines fit more than one equally well.
+	SniffDelimiters = []rune{',', ';', '\t', '|'}
+)
+
+// Sniff guesses the delimiter of the CSV text that starts with sample. It
+// takes the delimiter found the same number of times on every line, and
+// falls back to the one found on most lines. Delimiters in quoted fields and
+// comment lines are not counted. It returns ',' when none is found.
+func Sniff(sample []byte, comment rune) rune {
+	lines := sniffedLines(string(sample), comment)
+	best := ','
+	bestConsistent := false
+	bestScore := 0
+	for _, delim := range SniffDelimiters {
+		consistent := true
+		score := 0
+		first := -1
+		for _, line := range lines {
+			count := countDelimiter(line, delim)
+			if first < 0 {
+				first = count
+			}
+			if count != first {
+				consistent = false
+			}
+			if count > 0 {
+				score++
+			}
+		}
+		consistent = consistent && first > 0
+		if consistent && !bestConsistent || consistent == bestConsistent && score > bestScore {
+			best = delim
+			bestConsistent = consistent
+			bestScore = score
+		}
+	}
+	return best
+}
+
+func sniffedLines(sample string, comment rune) []string {
+	lines := strings.Split(sample, "\n")
+	if len(sample) >= sniffSize && len(lines) > 1 {
+		// The last line is cut short.
+		lines = lines[:len(lines)-1]
+	}
+	sniffed := []string{}
+	for _, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if len(strings.TrimSpace(line)) <= 0 {
+			continue
+		}
+		if comment != 0 {
+			r, _ := utf8.DecodeRuneInString(line)
+			if r == comment {
+				continue
+			}
+		}
+		sniffed = append(sniffed, line)
+		if len(sniffed) >= sniffLines {
+			break
+		}
+	}
+	return sniffed
+}
+
+func countDelimiter(line string, delim rune) int {
+	count := 0
+	quoted := false
+	for _, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == delim && !quoted {
+			count++
+		}
+	}
+	return count
+}
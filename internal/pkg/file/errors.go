@@ -13,8 +13,9 @@
 // limitations under the License.
 package file
 
-import "github.com/kenita8/errors"
+import "github.com/kenita8/xlcmd/internal/pkg/errors"
 
 var (
 	ErrNotFoundEncoding = errors.New("the provided encoding is not supported")
+	ErrSpool            = errors.New("unable to write temporary file")
 )
@@ -17,6 +17,7 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"strings"
 
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/ianaindex"
@@ -35,6 +36,9 @@ func (r *File) OpenFile(name string, flag int, perm os.FileMode) (*os.File, erro
 }
 
 func (r *File) Encoding(name string) (encoding.Encoding, error) {
+	if strings.EqualFold(name, AutoEncodingName) {
+		return Auto, nil
+	}
 	e, err := ianaindex.IANA.Encoding(name)
 	if err != nil {
 		return nil, err
@@ -60,3 +64,19 @@ func (r *File) Close(f io.Closer) error {
 func (r *File) Stat(name string) (fs.FileInfo, error) {
 	return os.Stat(name)
 }
+
+// Spool copies r to a new temporary file in dir whose name ends with name, so
+// it keeps the extension, and returns its path. The caller removes the file.
+func Spool(r io.Reader, dir string, name string) (string, error) {
+	f, err := os.CreateTemp(dir, "*-"+name)
+	if err != nil {
+		return "", ErrSpool.Details("name", name).Wrap(err)
+	}
+	defer f.Close()
+	_, err = io.Copy(f, r)
+	if err != nil {
+		os.Remove(f.Name())
+		return "", ErrSpool.Details("name", name).Wrap(err)
+	}
+	return f.Name(), nil
+}
@@ -15,9 +15,13 @@ package file
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
 )
 
 func TestFile(t *testing.T) {
@@ -42,3 +46,54 @@ func TestFile(t *testing.T) {
 	_, err = fl.Stat("file_test.go")
 	assert.Nil(t, err)
 }
+
+func TestDetectEncoding(t *testing.T) {
+	testcases := []struct {
+		head   []byte
+		expect encoding.Encoding
+	}{
+		{[]byte("\xEF\xBB\xBFabc"), unicode.UTF8BOM},
+		{[]byte("\xFF\xFEa\x00b\x00"), unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)},
+		{[]byte("\xFE\xFF\x00a\x00b"), unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)},
+		{[]byte("a\x00b\x00c\x00"), unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)},
+		{[]byte("abc"), unicode.UTF8},
+		{[]byte{}, unicode.UTF8},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expect, DetectEncoding(tc.head))
+		})
+	}
+
+	fl := File{}
+	enc, err := fl.Encoding("AUTO")
+	assert.Nil(t, err)
+	assert.Equal(t, Auto, enc)
+}
+
+func TestStringWidth(t *testing.T) {
+	testcases := []struct {
+		str    string
+		expect int
+	}{
+		{"abc", 3},
+		{"日本語", 6},
+		{"ｶﾅ", 2},
+		{"Ａ1", 3},
+		{"é", 1},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expect, StringWidth(tc.str))
+		})
+	}
+}
+
+func TestSpool(t *testing.T) {
+	pathname, err := Spool(strings.NewReader("a,b\n"), t.TempDir(), "stdin.csv")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(pathname, "-stdin.csv"))
+	data, err := os.ReadFile(pathname)
+	assert.Nil(t, err)
+	assert.Equal(t, "a,b\n", string(data))
+}
@@ -0,0 +1,182 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package fixed
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+	"github.com/kenita8/xlcmd/internal/pkg/file/txt"
+	"gopkg.in/yaml.v2"
+)
+
+type Trim string
+
+const (
+	TrimBoth  Trim = "both"
+	TrimLeft  Trim = "left"
+	TrimRight Trim = "right"
+	TrimNone  Trim = "none"
+)
+
+// Column is a field of a fixed-width line. Start and End are 1-based and
+// inclusive, counted in display columns, so a full-width character takes two.
+type Column struct {
+	Name  string `yaml:"Name"`
+	Start int    `yaml:"Start"`
+	End   int    `yaml:"End"`
+	Trim  Trim   `yaml:"Trim"`
+}
+
+type Spec struct {
+	Columns []Column `yaml:"Columns"`
+}
+
+// ParseWidths makes consecutive columns from a list of widths, e.g. "10,8,20".
+func ParseWidths(widths string) (*Spec, error) {
+	spec := &Spec{}
+	start := 1
+	for _, w := range strings.Split(widths, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(w))
+		if err != nil || n <= 0 {
+			return nil, ErrInvalidWidths.Details("widths", widths)
+		}
+		spec.Columns = append(spec.Columns, Column{Start: start, End: start + n - 1, Trim: TrimBoth})
+		start += n
+	}
+	return spec, nil
+}
+
+// LoadSpec reads a column specification from a YAML file.
+func LoadSpec(pathname string) (*Spec, error) {
+	data, err := os.ReadFile(pathname)
+	if err != nil {
+		return nil, ErrLoadSpec.Details("path", pathname).Wrap(err)
+	}
+	spec := &Spec{}
+	err = yaml.UnmarshalStrict(data, spec)
+	if err != nil {
+		return nil, ErrLoadSpec.Details("path", pathname).Wrap(err)
+	}
+	if len(spec.Columns) <= 0 {
+		return nil, ErrInvalidSpec.Details("path", pathname, "reason", "no columns")
+	}
+	for i := range spec.Columns {
+		column := &spec.Columns[i]
+		if len(column.Trim) <= 0 {
+			column.Trim = TrimBoth
+		}
+		switch {
+		case column.Start <= 0 || column.End < column.Start:
+			return nil, ErrInvalidSpec.Details("path", pathname, "column", i+1, "reason", "start and end must be 1 <= start <= end")
+		case column.Trim != TrimBoth && column.Trim != TrimLeft && column.Trim != TrimRight && column.Trim != TrimNone:
+			return nil, ErrInvalidSpec.Details("path", pathname, "column", i+1, "reason", "trim must be both, left, right, or none")
+		}
+	}
+	return spec, nil
+}
+
+// header returns the column names, or nil when no column is named.
+func (s *Spec) header() []string {
+	named := false
+	names := make([]string, len(s.Columns))
+	for i, column := range s.Columns {
+		names[i] = column.Name
+		named = named || len(column.Name) > 0
+	}
+	if !named {
+		return nil
+	}
+	return names
+}
+
+// FixedFile reads lines cut into fields at fixed display columns. When the
+// spec names its columns, the names are returned as the first row.
+type FixedFile struct {
+	txt.TxtFile
+	Spec    *Spec
+	pending []string
+}
+
+func NewFixedFile(pathname string, encoding string, spec *Spec) *FixedFile {
+	fixed := &FixedFile{
+		TxtFile: txt.TxtFile{
+			Pathname: pathname,
+			EncName:  encoding,
+			Filer:    &file.File{},
+		},
+		Spec: spec,
+	}
+	fixed.TxtFile.TxtFiler = fixed
+	return fixed
+}
+
+func (f *FixedFile) OpenReadModeInternal() error {
+	f.pending = f.Spec.header()
+	return f.TxtFile.OpenReadModeInternal()
+}
+
+func (f *FixedFile) ReadOneLine() ([]string, error) {
+	if f.pending != nil {
+		header := f.pending
+		f.pending = nil
+		return header, nil
+	}
+	values, err := f.TxtFile.ReadOneLine()
+	if err != nil {
+		return nil, err
+	}
+	return f.Spec.Split(values[0]), nil
+}
+
+// Split cuts line into the columns of the spec. A character belongs to the
+// column its first display column falls in, and a zero-width character such
+// as a combining mark stays with the character before it.
+func (s *Spec) Split(line string) []string {
+	fields := make([]strings.Builder, len(s.Columns))
+	pos := 1
+	at := 1
+	for _, r := range line {
+		w := file.RuneWidth(r)
+		if w > 0 {
+			at = pos
+		}
+		for i, column := range s.Columns {
+			if column.Start <= at && at <= column.End {
+				fields[i].WriteRune(r)
+			}
+		}
+		pos += w
+	}
+	values := make([]string, len(s.Columns))
+	for i, column := range s.Columns {
+		values[i] = trim(fields[i].String(), column.Trim)
+	}
+	return values
+}
+
+func trim(value string, mode Trim) string {
+	switch mode {
+	case TrimLeft:
+		return strings.TrimLeftFunc(value, unicode.IsSpace)
+	case TrimRight:
+		return strings.TrimRightFunc(value, unicode.IsSpace)
+	case TrimNone:
+		return value
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package fixed
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOneLine(t *testing.T) {
+	widths, err := ParseWidths("6,10,4")
+	assert.Nil(t, err)
+	spec, err := LoadSpec("testdata/report.yml")
+	assert.Nil(t, err)
+	testcases := []struct {
+		spec       *Spec
+		expectData [][]string
+	}{
+		{
+			spec: widths,
+			expectData: [][]string{
+				{"A001", "東京都", "12.5"},
+				{"B002", "Osaka", "7.0"},
+				{"C003", "北海道ｶﾅ", "100"},
+			},
+		},
+		{
+			spec: spec,
+			expectData: [][]string{
+				{"Code", "City", "Value"},
+				{"A001", "東京都", "12.5"},
+				{"B002", "Osaka", "7.0"},
+				{"C003", "北海道ｶﾅ", "100"},
+			},
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			f := NewFixedFile("testdata/report.txt", "UTF-8", tc.spec)
+			assert.Nil(t, f.OpenReadMode())
+			defer f.Close()
+			actualData := [][]string{}
+			for {
+				values, err := f.ReadOneLine()
+				if err == io.EOF {
+					break
+				}
+				assert.Nil(t, err)
+				actualData = append(actualData, values)
+			}
+			assert.Equal(t, tc.expectData, actualData)
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	testcases := []struct {
+		columns []Column
+		line    string
+		expect  []string
+	}{
+		{[]Column{{Start: 1, End: 2, Trim: TrimNone}, {Start: 3, End: 4, Trim: TrimNone}}, "日本", []string{"日", "本"}},
+		{[]Column{{Start: 1, End: 3, Trim: TrimNone}, {Start: 4, End: 5, Trim: TrimNone}}, "a日本", []string{"a日", "本"}},
+		{[]Column{{Start: 1, End: 1, Trim: TrimNone}, {Start: 2, End: 2, Trim: TrimNone}}, "éx", []string{"é", "x"}},
+		{[]Column{{Start: 1, End: 4, Trim: TrimLeft}, {Start: 5, End: 8, Trim: TrimRight}}, "  ab  cd", []string{"ab", "  cd"}},
+		{[]Column{{Start: 1, End: 2, Trim: TrimBoth}, {Start: 3, End: 6, Trim: TrimBoth}}, "ab", []string{"ab", ""}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			spec := &Spec{Columns: tc.columns}
+			assert.Equal(t, tc.expect, spec.Split(tc.line))
+		})
+	}
+}
+
+func TestParseWidths(t *testing.T) {
+	testcases := []struct {
+		widths    string
+		expect    []Column
+		expectErr error
+	}{
+		{"3, 2", []Column{{Start: 1, End: 3, Trim: TrimBoth}, {Start: 4, End: 5, Trim: TrimBoth}}, nil},
+		{"3,0", nil, ErrInvalidWidths},
+		{"a", nil, ErrInvalidWidths},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			spec, err := ParseWidths(tc.widths)
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tc.expect, spec.Columns)
+			}
+		})
+	}
+}
+
+func TestLoadSpec(t *testing.T) {
+	testcases := []struct {
+		data      string
+		expectErr error
+	}{
+		{"Columns:\n  - Name: Code\n    Start: 1\n    End: 4\n", nil},
+		{"Columns:\n  - Name: Code\n    Start: 1\n    Ende: 4\n", ErrLoadSpec},
+		{"Column:\n  - Name: Code\n", ErrLoadSpec},
+		{"Columns: []\n", ErrInvalidSpec},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			pathname := filepath.Join(t.TempDir(), "spec.yml")
+			assert.Nil(t, os.WriteFile(pathname, []byte(tc.data), 0644))
+			_, err := LoadSpec(pathname)
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,483 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package json
+
+import (
+	"bufio"
+	rawJson "encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+	"github.com/kenita8/xlcmd/internal/pkg/file/txt"
+)
+
+// ArrayPolicy decides how array values are written.
+type ArrayPolicy string
+
+const (
+	// ArrayJoin joins the elements into one cell separated by ArraySeparator.
+	ArrayJoin ArrayPolicy = "join"
+	// ArrayExplode writes one row per element, repeating the other columns.
+	ArrayExplode ArrayPolicy = "explode"
+	// ArrayJson keeps the array as JSON text.
+	ArrayJson ArrayPolicy = "json"
+)
+
+const (
+	ArraySeparator = ","
+	// valueKey names the column of a record that is not an object.
+	valueKey = "value"
+)
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+// object keeps the keys of a JSON object in document order.
+type object []field
+
+type cell struct {
+	key   string
+	value string
+}
+
+type record []cell
+
+// JsonFile reads a JSON array of objects, or a stream of JSON values such as
+// NDJSON, as rows. Nested objects are flattened into dotted column names and
+// the header is the union of the keys of all records, in order of appearance.
+// A flattened name that is already taken in a record, such as "a.b" of
+// {"a.b":1,"a":{"b":2}}, is numbered like "a.b 2".
+type JsonFile struct {
+	txt.TxtFile
+	Arrays  ArrayPolicy
+	Lines   bool
+	reader  *jsonReader
+	header  []string
+	index   map[string]int
+	pending [][]string
+}
+
+func NewJsonFile(pathname string, encoding string) *JsonFile {
+	json := &JsonFile{
+		TxtFile: txt.TxtFile{
+			Pathname: pathname,
+			EncName:  encoding,
+			Filer:    &file.File{},
+		},
+		Arrays: ArrayJoin,
+	}
+	json.TxtFile.TxtFiler = json
+	return json
+}
+
+// NewNdjsonFile reads one JSON value per line. A top-level array is a single
+// record rather than a list of records. A line that is not a JSON value is
+// returned as a txt.RowError, and reading goes on with the next line.
+func NewNdjsonFile(pathname string, encoding string) *JsonFile {
+	json := NewJsonFile(pathname, encoding)
+	json.Lines = true
+	return json
+}
+
+// OpenReadModeInternal scans the keys of the whole input to build the header
+// before the first row is returned. Only the keys are kept, and the rows are
+// read on the second pass.
+func (j *JsonFile) OpenReadModeInternal() error {
+	header, err := j.scanHeader()
+	if err != nil {
+		return err
+	}
+	j.header = header
+	j.index = map[string]int{}
+	for i, key := range header {
+		j.index[key] = i
+	}
+	j.reader, err = j.newReader(j.Rc)
+	if err != nil {
+		return err
+	}
+	j.pending = [][]string{header}
+	return nil
+}
+
+func (j *JsonFile) scanHeader() ([]string, error) {
+	fp, err := j.Filer.OpenFile(j.Pathname, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer j.Filer.Close(fp)
+	enc, err := j.Filer.Encoding(j.EncName)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := j.newReader(j.Filer.NewReader(fp, enc))
+	if err != nil {
+		return nil, err
+	}
+	header := []string{}
+	seen := map[string]bool{}
+	for {
+		records, err := j.nextRecords(reader)
+		if err == io.EOF {
+			break
+		}
+		var rowErr *txt.RowError
+		if errors.As(err, &rowErr) {
+			// The line is returned again when the rows are read.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range records {
+			for _, c := range rec {
+				if !seen[c.key] {
+					seen[c.key] = true
+					header = append(header, c.key)
+				}
+			}
+		}
+	}
+	return header, nil
+}
+
+// jsonReader is a pass over the input. NDJSON is read a line at a time, so
+// that a bad line can be left out, while other input goes through one decoder.
+type jsonReader struct {
+	decoder *rawJson.Decoder
+	inArray bool
+	lines   *bufio.Reader
+	line    int
+}
+
+func (j *JsonFile) newReader(r io.Reader) (*jsonReader, error) {
+	br := bufio.NewReader(r)
+	if j.Lines {
+		return &jsonReader{lines: br}, nil
+	}
+	first, err := firstByte(br)
+	if err != nil && err != io.EOF {
+		return nil, ErrDecodeJson.Wrap(err)
+	}
+	reader := &jsonReader{decoder: rawJson.NewDecoder(br), inArray: first == '['}
+	reader.decoder.UseNumber()
+	if reader.inArray {
+		_, err = reader.decoder.Token()
+		if err != nil {
+			return nil, ErrDecodeJson.Wrap(err)
+		}
+	}
+	return reader, nil
+}
+
+func firstByte(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if b[0] == 0xEF {
+			// UTF-8 byte order mark
+			bom, err := br.Peek(3)
+			if err == nil && string(bom) == "\xEF\xBB\xBF" {
+				br.Discard(3)
+				continue
+			}
+		}
+		if !unicode.IsSpace(rune(b[0])) {
+			return b[0], nil
+		}
+		br.Discard(1)
+	}
+}
+
+// nextRecords returns errors without the name of the file, which the caller
+// adds.
+func (j *JsonFile) nextRecords(reader *jsonReader) ([]record, error) {
+	if reader.lines != nil {
+		return j.nextLine(reader)
+	}
+	if reader.inArray && !reader.decoder.More() {
+		return nil, io.EOF
+	}
+	value, err := decodeValue(reader.decoder)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, ErrDecodeJson.Wrap(err)
+	}
+	return j.records(value), nil
+}
+
+// nextLine returns the records of the next line that is not blank.
+func (j *JsonFile) nextLine(reader *jsonReader) ([]record, error) {
+	for {
+		text, err := reader.lines.ReadString('\n')
+		if err == io.EOF && len(text) <= 0 {
+			return nil, io.EOF
+		}
+		if err != nil && err != io.EOF {
+			return nil, ErrDecodeJson.Wrap(err)
+		}
+		reader.line += 1
+		if reader.line == 1 {
+			text = strings.TrimPrefix(text, "\xEF\xBB\xBF")
+		}
+		if len(strings.TrimSpace(text)) <= 0 {
+			continue
+		}
+		value, err := decodeLine(text)
+		if err != nil {
+			return nil, &txt.RowError{Line: reader.line, Err: ErrDecodeJson.Wrap(err)}
+		}
+		return j.records(value), nil
+	}
+}
+
+// decodeLine decodes a line that holds one JSON value.
+func decodeLine(text string) (interface{}, error) {
+	decoder := rawJson.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	value, err := decodeValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	_, err = decoder.Token()
+	if err == nil {
+		return nil, ErrTrailingJson
+	}
+	if err != io.EOF {
+		return nil, err
+	}
+	return value, nil
+}
+
+// decodeValue returns io.EOF only when the input ends before the value. An
+// input that ends inside it is io.ErrUnexpectedEOF.
+func decodeValue(decoder *rawJson.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(rawJson.Delim)
+	if !ok {
+		return token, nil
+	}
+	value, err := decodeContainer(decoder, delim)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return value, err
+}
+
+func decodeContainer(decoder *rawJson.Decoder, delim rawJson.Delim) (interface{}, error) {
+	switch delim {
+	case '{':
+		obj := object{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, field{key: key.(string), value: value})
+		}
+		_, err := decoder.Token()
+		return obj, err
+	case '[':
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := decoder.Token()
+		return array, err
+	}
+	return nil, ErrUnexpectedDelim.Details("delim", delim.String())
+}
+
+// records flattens a value into records with unique keys.
+func (j *JsonFile) records(value interface{}) []record {
+	records := j.flatten("", value)
+	for _, rec := range records {
+		uniqueKeys(rec)
+	}
+	return records
+}
+
+// uniqueKeys numbers a key already taken by an earlier cell of the record.
+func uniqueKeys(rec record) {
+	seen := map[string]bool{}
+	for i, c := range rec {
+		key := c.key
+		for n := 2; seen[key]; n++ {
+			key = c.key + " " + strconv.Itoa(n)
+		}
+		seen[key] = true
+		rec[i].key = key
+	}
+}
+
+func joinKey(prefix string, key string) string {
+	if len(prefix) <= 0 {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// flatten turns a value into records. It returns more than one record only
+// when arrays are exploded.
+func (j *JsonFile) flatten(prefix string, value interface{}) []record {
+	switch v := value.(type) {
+	case object:
+		records := []record{{}}
+		for _, f := range v {
+			records = product(records, j.flatten(joinKey(prefix, f.key), f.value))
+		}
+		return records
+	case []interface{}:
+		if j.Arrays == ArrayExplode {
+			if len(v) <= 0 {
+				return []record{{{key: keyOrValue(prefix)}}}
+			}
+			records := []record{}
+			for _, elem := range v {
+				records = append(records, j.flatten(prefix, elem)...)
+			}
+			return records
+		}
+		if j.Arrays == ArrayJson {
+			return []record{{{key: keyOrValue(prefix), value: jsonText(v)}}}
+		}
+		texts := make([]string, len(v))
+		for i, elem := range v {
+			texts[i] = scalarText(elem)
+		}
+		return []record{{{key: keyOrValue(prefix), value: strings.Join(texts, ArraySeparator)}}}
+	}
+	return []record{{{key: keyOrValue(prefix), value: scalarText(value)}}}
+}
+
+func keyOrValue(key string) string {
+	if len(key) <= 0 {
+		return valueKey
+	}
+	return key
+}
+
+func product(left []record, right []record) []record {
+	if len(right) == 1 {
+		for i := range left {
+			left[i] = append(left[i], right[0]...)
+		}
+		return left
+	}
+	records := make([]record, 0, len(left)*len(right))
+	for _, l := range left {
+		for _, r := range right {
+			rec := make(record, 0, len(l)+len(r))
+			rec = append(append(rec, l...), r...)
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+func scalarText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case rawJson.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	}
+	return jsonText(value)
+}
+
+// jsonText writes a decoded value back as compact JSON, keeping key order.
+func jsonText(value interface{}) string {
+	var sb strings.Builder
+	writeJson(&sb, value)
+	return sb.String()
+}
+
+func writeJson(sb *strings.Builder, value interface{}) {
+	switch v := value.(type) {
+	case object:
+		sb.WriteByte('{')
+		for i, f := range v {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			key, _ := rawJson.Marshal(f.key)
+			sb.Write(key)
+			sb.WriteByte(':')
+			writeJson(sb, f.value)
+		}
+		sb.WriteByte('}')
+	case []interface{}:
+		sb.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeJson(sb, elem)
+		}
+		sb.WriteByte(']')
+	case rawJson.Number:
+		sb.WriteString(v.String())
+	default:
+		text, _ := rawJson.Marshal(v)
+		sb.Write(text)
+	}
+}
+
+func (j *JsonFile) ReadOneLine() ([]string, error) {
+	for len(j.pending) <= 0 {
+		records, err := j.nextRecords(j.reader)
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range records {
+			values := make([]string, len(j.header))
+			for _, c := range rec {
+				values[j.index[c.key]] = c.value
+			}
+			j.pending = append(j.pending, values)
+		}
+	}
+	values := j.pending[0]
+	j.pending = j.pending[1:]
+	return values, nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package json
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/kenita8/xlcmd/internal/pkg/file/txt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOneLine(t *testing.T) {
+	testcases := []struct {
+		json       *JsonFile
+		arrays     ArrayPolicy
+		expectData [][]string
+	}{
+		{
+			json:   NewJsonFile("testdata/array.json", "UTF-8"),
+			arrays: ArrayJoin,
+			expectData: [][]string{
+				{"id", "host.name", "host.ip", "tags", "ok", "note"},
+				{"1", "web01", "10.0.0.1", "a,b", "", ""},
+				{"2", "web02", "", "", "true", ""},
+			},
+		},
+		{
+			json:   NewJsonFile("testdata/array.json", "UTF-8"),
+			arrays: ArrayExplode,
+			expectData: [][]string{
+				{"id", "host.name", "host.ip", "tags", "ok", "note"},
+				{"1", "web01", "10.0.0.1", "a", "", ""},
+				{"1", "web01", "10.0.0.1", "b", "", ""},
+				{"2", "web02", "", "", "true", ""},
+			},
+		},
+		{
+			json:   NewJsonFile("testdata/array.json", "UTF-8"),
+			arrays: ArrayJson,
+			expectData: [][]string{
+				{"id", "host.name", "host.ip", "tags", "ok", "note"},
+				{"1", "web01", "10.0.0.1", `["a","b"]`, "", ""},
+				{"2", "web02", "", "[]", "true", ""},
+			},
+		},
+		{
+			json:   NewNdjsonFile("testdata/lines.ndjson", "UTF-8"),
+			arrays: ArrayJoin,
+			expectData: [][]string{
+				{"time", "level", "msg", "ctx.user", "ctx.ms"},
+				{"2024-07-15T14:11:18Z", "info", "started", "田中", ""},
+				{"2024-07-15T14:11:20Z", "warn", "slow", "", "12345678901234567890"},
+			},
+		},
+		{
+			json:   NewJsonFile("testdata/collide.json", "UTF-8"),
+			arrays: ArrayExplode,
+			expectData: [][]string{
+				{"a.b", "a.b 2", "c", "c 2"},
+				{"1", "2", "x", ""},
+				{"1", "2", "y", ""},
+				{"3", "", "z", "w"},
+			},
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tc.json.Arrays = tc.arrays
+			err := tc.json.OpenReadMode()
+			assert.Nil(t, err)
+			defer tc.json.Close()
+			actualData := [][]string{}
+			for {
+				values, err := tc.json.ReadOneLine()
+				if err == io.EOF {
+					break
+				}
+				assert.Nil(t, err)
+				actualData = append(actualData, values)
+			}
+			assert.Equal(t, tc.expectData, actualData)
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	value := object{
+		{key: "a", value: []interface{}{object{{key: "x", value: "1"}}, object{{key: "x", value: "2"}}}},
+		{key: "b", value: []interface{}{"p", "q"}},
+	}
+	testcases := []struct {
+		arrays ArrayPolicy
+		expect []record
+	}{
+		{ArrayExplode, []record{
+			{{"a.x", "1"}, {"b", "p"}},
+			{{"a.x", "1"}, {"b", "q"}},
+			{{"a.x", "2"}, {"b", "p"}},
+			{{"a.x", "2"}, {"b", "q"}},
+		}},
+		{ArrayJoin, []record{{{"a", `{"x":"1"},{"x":"2"}`}, {"b", "p,q"}}}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			j := &JsonFile{Arrays: tc.arrays}
+			assert.Equal(t, tc.expect, j.flatten("", value))
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	testcases := []*JsonFile{
+		NewJsonFile("testdata/broken.json", "UTF-8"),
+		// a stream of values that ends inside one
+		NewJsonFile("testdata/truncated.ndjson", "UTF-8"),
+	}
+	for i, j := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := j.OpenReadMode()
+			assert.ErrorIs(t, err, ErrDecodeJson)
+		})
+	}
+}
+
+func TestReadOneLineRowError(t *testing.T) {
+	testcases := []struct {
+		pathname   string
+		expectData [][]string
+		expectRows []int
+	}{
+		{
+			pathname:   "testdata/badlines.ndjson",
+			expectData: [][]string{{"a", "b"}, {"1", ""}, {"4", "x"}},
+			expectRows: []int{2, 4, 5},
+		},
+		{
+			pathname:   "testdata/truncated.ndjson",
+			expectData: [][]string{{"a"}, {"1"}},
+			expectRows: []int{2},
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			j := NewNdjsonFile(tc.pathname, "UTF-8")
+			assert.Nil(t, j.OpenReadMode())
+			defer j.Close()
+			actualData := [][]string{}
+			actualRows := []int{}
+			for {
+				values, err := j.ReadOneLine()
+				if err == io.EOF {
+					break
+				}
+				var rowErr *txt.RowError
+				if errors.As(err, &rowErr) {
+					assert.ErrorIs(t, err, ErrDecodeJson)
+					actualRows = append(actualRows, rowErr.Line)
+					continue
+				}
+				assert.Nil(t, err)
+				actualData = append(actualData, values)
+			}
+			assert.Equal(t, tc.expectData, actualData)
+			assert.Equal(t, tc.expectRows, actualRows)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package logfile
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/kenita8/xlcmd/internal/pkg/file"
+	"github.com/kenita8/xlcmd/internal/pkg/file/txt"
+)
+
+var (
+	// Presets are patterns for well-known log formats.
+	Presets = map[string]string{
+		"common": `^(?P<host>\S+) (?P<ident>\S+) (?P<user>\S+) \[(?P<time>[^\]]+)\] "(?P<request>[^"]*)" ` +
+			`(?P<status>\d{3}) (?P<size>\S+)$`,
+		"combined": `^(?P<host>\S+) (?P<ident>\S+) (?P<user>\S+) \[(?P<time>[^\]]+)\] "(?P<request>[^"]*)" ` +
+			`(?P<status>\d{3}) (?P<size>\S+) "(?P<referer>[^"]*)" "(?P<agent>[^"]*)"`,
+		"syslog": `^(?:<(?P<priority>\d+)>)?(?P<time>[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) (?P<host>\S+) ` +
+			`(?P<program>[^:\[\s]+)(?:\[(?P<pid>\d+)\])?: (?P<message>.*)$`,
+	}
+	unmatchedHeader = []string{"File", "Line", "Text"}
+)
+
+// LogFile reads lines that match a regular expression. Every named group of
+// the pattern becomes a column, and the group names are the header row.
+// Lines that do not match are counted and left out.
+type LogFile struct {
+	txt.TxtFile
+	Pattern   *regexp.Regexp
+	Invert    bool
+	header    []string
+	groups    []int
+	line      int
+	unmatched int
+	started   bool
+}
+
+func NewLogFile(pathname string, encoding string, pattern *regexp.Regexp) *LogFile {
+	log := &LogFile{
+		TxtFile: txt.TxtFile{
+			Pathname: pathname,
+			EncName:  encoding,
+			Filer:    &file.File{},
+		},
+		Pattern: pattern,
+	}
+	log.TxtFile.TxtFiler = log
+	return log
+}
+
+// Compile compiles a preset name or a pattern, which must have a named group.
+func Compile(pattern string) (*regexp.Regexp, error) {
+	if preset, ok := Presets[pattern]; ok {
+		pattern = preset
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, ErrCompilePattern.Details("pattern", pattern).Wrap(err)
+	}
+	for _, name := range re.SubexpNames() {
+		if len(name) > 0 {
+			return re, nil
+		}
+	}
+	return nil, ErrNoNamedGroup.Details("pattern", pattern)
+}
+
+// UnmatchedFile returns a reader of the lines this file leaves out, with the
+// file name and line number of each.
+func (l *LogFile) UnmatchedFile() *LogFile {
+	unmatched := NewLogFile(l.Pathname, l.EncName, l.Pattern)
+	unmatched.Filer = l.Filer
+	unmatched.Name = l.Name
+	unmatched.Invert = true
+	return unmatched
+}
+
+// Unmatched returns the number of lines left out by the last read.
+func (l *LogFile) Unmatched() int {
+	return l.unmatched
+}
+
+func (l *LogFile) OpenReadModeInternal() error {
+	l.header = []string{}
+	l.groups = []int{}
+	for i, name := range l.Pattern.SubexpNames() {
+		if len(name) > 0 {
+			l.header = append(l.header, name)
+			l.groups = append(l.groups, i)
+		}
+	}
+	if l.Invert {
+		l.header = unmatchedHeader
+	}
+	l.line = 0
+	l.unmatched = 0
+	l.started = false
+	return l.TxtFile.OpenReadModeInternal()
+}
+
+func (l *LogFile) ReadOneLine() ([]string, error) {
+	if !l.started {
+		l.started = true
+		return l.header, nil
+	}
+	for {
+		values, err := l.TxtFile.ReadOneLine()
+		if err != nil {
+			return nil, err
+		}
+		l.line++
+		match := l.Pattern.FindStringSubmatch(values[0])
+		if match == nil {
+			l.unmatched++
+			if l.Invert {
+				return []string{l.Filename(), strconv.Itoa(l.line), values[0]}, nil
+			}
+			continue
+		}
+		if l.Invert {
+			continue
+		}
+		columns := make([]string, len(l.groups))
+		for i, group := range l.groups {
+			columns[i] = match[group]
+		}
+		return columns, nil
+	}
+}
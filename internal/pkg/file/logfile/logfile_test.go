@@ -0,0 +1,117 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package logfile
+
+import (
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(t *testing.T, l *LogFile) [][]string {
+	err := l.OpenReadMode()
+	assert.Nil(t, err)
+	defer l.Close()
+	data := [][]string{}
+	for {
+		values, err := l.ReadOneLine()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		data = append(data, values)
+	}
+	return data
+}
+
+func TestReadOneLine(t *testing.T) {
+	testcases := []struct {
+		pathname        string
+		pattern         string
+		expectData      [][]string
+		expectUnmatched int
+	}{
+		{
+			pathname: "testdata/access.log",
+			pattern:  "combined",
+			expectData: [][]string{
+				{"host", "ident", "user", "time", "request", "status", "size", "referer", "agent"},
+				{"127.0.0.1", "-", "frank", "10/Oct/2000:13:55:36 -0700", "GET /apache_pb.gif HTTP/1.0", "200", "2326",
+					"http://www.example.com/start.html", "Mozilla/4.08"},
+				{"10.0.0.2", "-", "-", "10/Oct/2000:13:55:37 -0700", "POST /login HTTP/1.1", "302", "-", "-", "curl/8.0"},
+			},
+			expectUnmatched: 1,
+		},
+		{
+			pathname: "testdata/syslog.log",
+			pattern:  "syslog",
+			expectData: [][]string{
+				{"priority", "time", "host", "program", "pid", "message"},
+				{"", "Jul 15 14:11:18", "web01", "sshd", "1234", "Accepted publickey for kenita"},
+				{"13", "Jul  5 09:01:02", "web01", "cron", "", "job started"},
+			},
+		},
+		{
+			pathname: "testdata/access.log",
+			pattern:  `^(?P<ip>[\d.]+) .* (?P<status>\d{3}) \S+ `,
+			expectData: [][]string{
+				{"ip", "status"},
+				{"127.0.0.1", "200"},
+				{"10.0.0.2", "302"},
+			},
+			expectUnmatched: 1,
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			re, err := Compile(tc.pattern)
+			assert.Nil(t, err)
+			l := NewLogFile(tc.pathname, "UTF-8", re)
+			assert.Equal(t, tc.expectData, readAll(t, l))
+			assert.Equal(t, tc.expectUnmatched, l.Unmatched())
+		})
+	}
+}
+
+func TestUnmatchedFile(t *testing.T) {
+	re, err := Compile("combined")
+	assert.Nil(t, err)
+	l := NewLogFile("testdata/access.log", "UTF-8", re)
+	expect := [][]string{{"File", "Line", "Text"}, {"testdata/access.log", "2", "garbage line"}}
+	assert.Equal(t, expect, readAll(t, l.UnmatchedFile()))
+}
+
+func TestCompile(t *testing.T) {
+	testcases := []struct {
+		pattern   string
+		expectErr error
+	}{
+		{"common", nil},
+		{`(?P<a>\d+)`, nil},
+		{`(\d+)`, ErrNoNamedGroup},
+		{`(?P<a>\d+`, ErrCompilePattern},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := Compile(tc.pattern)
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
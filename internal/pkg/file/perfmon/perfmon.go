@@ -0,0 +1,222 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package perfmon
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/kenita8/xlcmd/internal/pkg/file/txt"
+)
+
+// TimestampLayout is the layout of the timestamps of a log, such as
+// "07/15/2024 14:11:18.978". The milliseconds are parsed without a layout.
+const TimestampLayout = "01/02/2006 15:04:05"
+
+var (
+	// counterHeader is the header row of a CounterFile.
+	counterHeader = []string{"Sheet", "Header", "Host", "Object", "Instance", "Counter"}
+)
+
+// Counter is a counter path, \\Host\Object(Instance)\Counter, and its parts.
+// Host and Instance are empty when the path has none.
+type Counter struct {
+	Path     string
+	Host     string
+	Object   string
+	Instance string
+	Counter  string
+}
+
+// ParseCounter splits a counter path. The second result is false when path
+// is not one, e.g. the timestamp header. An instance may hold backslashes,
+// e.g. "Paging File(\??\C:\pagefile.sys)".
+func ParseCounter(path string) (Counter, bool) {
+	c := Counter{Path: path}
+	rest := path
+	if strings.HasPrefix(rest, `\\`) {
+		host, after, ok := strings.Cut(rest[2:], `\`)
+		if !ok {
+			return c, false
+		}
+		c.Host = host
+		rest = after
+	} else if strings.HasPrefix(rest, `\`) {
+		rest = rest[1:]
+	} else {
+		return c, false
+	}
+	i := strings.IndexAny(rest, `(\`)
+	if i <= 0 {
+		return c, false
+	}
+	c.Object = rest[:i]
+	if rest[i] == '(' {
+		end := strings.LastIndex(rest, `)\`)
+		if end < i {
+			return c, false
+		}
+		c.Instance = rest[i+1 : end]
+		c.Counter = rest[end+2:]
+	} else {
+		c.Counter = rest[i+1:]
+	}
+	return c, len(c.Counter) > 0
+}
+
+// Name is the path without the host, Object(Instance)\Counter.
+func (c Counter) Name() string {
+	if len(c.Host) <= 0 {
+		return strings.TrimPrefix(c.Path, `\`)
+	}
+	return strings.TrimPrefix(c.Path, `\\`+c.Host+`\`)
+}
+
+// PerfmonFile reads a Performance Monitor log (PDH-CSV or PDH-TSV) through
+// the reader of its format. A missing sample, which the log writes as " ",
+// becomes an empty cell, and StripHost leaves the host out of the counter
+// paths of the header row.
+type PerfmonFile struct {
+	txt.TxtFiler
+	StripHost bool
+	counters  []Counter
+	line      int
+}
+
+func NewPerfmonFile(input txt.TxtFiler, stripHost bool) *PerfmonFile {
+	return &PerfmonFile{
+		TxtFiler:  input,
+		StripHost: stripHost,
+	}
+}
+
+func (p *PerfmonFile) OpenReadMode() error {
+	p.line = 0
+	return p.TxtFiler.OpenReadMode()
+}
+
+// ReadOneLine takes the first record as the header row, whose first column
+// is the timestamp and the others counter paths.
+func (p *PerfmonFile) ReadOneLine() ([]string, error) {
+	values, err := p.TxtFiler.ReadOneLine()
+	if err != nil {
+		return values, err
+	}
+	p.line++
+	if p.line == 1 {
+		p.counters = []Counter{}
+		for i := 1; i < len(values); i++ {
+			counter, ok := ParseCounter(values[i])
+			if !ok {
+				continue
+			}
+			if p.StripHost {
+				values[i] = counter.Name()
+			}
+			p.counters = append(p.counters, counter)
+		}
+		return values, nil
+	}
+	for i, value := range values {
+		if len(strings.TrimSpace(value)) <= 0 {
+			values[i] = ""
+		}
+	}
+	return values, nil
+}
+
+// SetName names the reader of the format when it can be named.
+func (p *PerfmonFile) SetName(name string) {
+	if namer, ok := p.TxtFiler.(txt.Namer); ok {
+		namer.SetName(name)
+	}
+}
+
+// LineNumber is the line of the reader of the format when it knows it.
+func (p *PerfmonFile) LineNumber() int {
+	if numberer, ok := p.TxtFiler.(txt.LineNumberer); ok {
+		return numberer.LineNumber()
+	}
+	return p.line
+}
+
+// Counters returns the counters of the header row of the last read.
+func (p *PerfmonFile) Counters() []Counter {
+	return p.counters
+}
+
+// CounterFile returns a reader of the counters of the last read, one row per
+// counter column with the sheet it went to, its header and its parts.
+func (p *PerfmonFile) CounterFile(sheet string) *CounterFile {
+	rows := [][]string{counterHeader}
+	for _, counter := range p.counters {
+		header := counter.Path
+		if p.StripHost {
+			header = counter.Name()
+		}
+		rows = append(rows, []string{sheet, header, counter.Host, counter.Object, counter.Instance, counter.Counter})
+	}
+	return &CounterFile{Pathname: p.Filename(), rows: rows}
+}
+
+// CounterFile reads rows kept in memory. Pathname is the log they come from.
+type CounterFile struct {
+	Pathname string
+	rows     [][]string
+	next     int
+}
+
+func (c *CounterFile) Basename() string {
+	return filepath.Base(c.Pathname)
+}
+
+func (c *CounterFile) Filename() string {
+	return c.Pathname
+}
+
+func (c *CounterFile) OpenReadMode() error {
+	return c.OpenReadModeInternal()
+}
+
+func (c *CounterFile) OpenReadModeInternal() error {
+	c.next = 0
+	return nil
+}
+
+func (c *CounterFile) ReadOneLine() ([]string, error) {
+	if c.next >= len(c.rows) {
+		return nil, io.EOF
+	}
+	c.next++
+	return append([]string{}, c.rows[c.next-1]...), nil
+}
+
+func (c *CounterFile) OpenWriteMode() error {
+	//Not implemented
+	return nil
+}
+
+func (c *CounterFile) OpenWriteModeInternal() error {
+	//Not implemented
+	return nil
+}
+
+func (c *CounterFile) WriteOneLine([]string) error {
+	//Not implemented
+	return nil
+}
+
+func (c *CounterFile) Close() {
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 kenita8
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package perfmon
+
+import (
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/kenita8/xlcmd/internal/pkg/file/tsv"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCounter(t *testing.T) {
+	testcases := []struct {
+		path     string
+		expect   Counter
+		expectOk bool
+	}{
+		{`\\LAPTOP\Processor(0)\% Processor Time`, Counter{Host: "LAPTOP", Object: "Processor", Instance: "0", Counter: "% Processor Time"}, true},
+		{`\\LAPTOP\Memory\Available MBytes`, Counter{Host: "LAPTOP", Object: "Memory", Counter: "Available MBytes"}, true},
+		{`\\LAPTOP\Paging File(\??\C:\pagefile.sys)\% Usage`, Counter{Host: "LAPTOP", Object: "Paging File", Instance: `\??\C:\pagefile.sys`, Counter: "% Usage"}, true},
+		{`\Process(chrome#2)\Handle Count`, Counter{Object: "Process", Instance: "chrome#2", Counter: "Handle Count"}, true},
+		{`(PDH-TSV 4.0) (Tokyo Standard Time)(-540)`, Counter{}, false},
+		{`\\LAPTOP`, Counter{}, false},
+		{`\\LAPTOP\Memory\`, Counter{}, false},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			counter, ok := ParseCounter(tc.path)
+			assert.Equal(t, tc.expectOk, ok)
+			if tc.expectOk {
+				tc.expect.Path = tc.path
+				assert.Equal(t, tc.expect, counter)
+			}
+		})
+	}
+}
+
+func TestReadOneLine(t *testing.T) {
+	testcases := []struct {
+		stripHost     bool
+		expectData    [][]string
+		expectHeaders []string
+	}{
+		{
+			stripHost: false,
+			expectData: [][]string{
+				{"(PDH-TSV 4.0) (Tokyo Standard Time)(-540)", `\\LAPTOP\Processor(_Total)\% Processor Time`, `\\LAPTOP\Memory\Available MBytes`, `\\LAPTOP\Paging File(\??\C:\pagefile.sys)\% Usage`},
+				{"07/15/2024 14:11:18.978", "", "2048", ""},
+				{"07/15/2024 14:11:19.978", "12.5", "2040", "3.1"},
+			},
+			expectHeaders: []string{`\\LAPTOP\Processor(_Total)\% Processor Time`, `\\LAPTOP\Memory\Available MBytes`, `\\LAPTOP\Paging File(\??\C:\pagefile.sys)\% Usage`},
+		},
+		{
+			stripHost: true,
+			expectData: [][]string{
+				{"(PDH-TSV 4.0) (Tokyo Standard Time)(-540)", `Processor(_Total)\% Processor Time`, `Memory\Available MBytes`, `Paging File(\??\C:\pagefile.sys)\% Usage`},
+				{"07/15/2024 14:11:18.978", "", "2048", ""},
+				{"07/15/2024 14:11:19.978", "12.5", "2040", "3.1"},
+			},
+			expectHeaders: []string{`Processor(_Total)\% Processor Time`, `Memory\Available MBytes`, `Paging File(\??\C:\pagefile.sys)\% Usage`},
+		},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			pf := NewPerfmonFile(tsv.NewTsvFile("testdata/cpu.tsv", "UTF-8"), tc.stripHost)
+			// A second read starts over with the header row.
+			for pass := 0; pass < 2; pass++ {
+				assert.Nil(t, pf.OpenReadMode())
+				actualData := [][]string{}
+				for {
+					values, err := pf.ReadOneLine()
+					if err == io.EOF {
+						break
+					}
+					assert.Nil(t, err)
+					actualData = append(actualData, values)
+				}
+				pf.Close()
+				assert.Equal(t, tc.expectData, actualData)
+				assert.Equal(t, 3, pf.LineNumber())
+			}
+
+			counters := pf.CounterFile("cpu")
+			assert.Nil(t, counters.OpenReadMode())
+			header, err := counters.ReadOneLine()
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"Sheet", "Header", "Host", "Object", "Instance", "Counter"}, header)
+			headers := []string{}
+			for {
+				values, err := counters.ReadOneLine()
+				if err == io.EOF {
+					break
+				}
+				assert.Nil(t, err)
+				assert.Equal(t, "cpu", values[0])
+				assert.Equal(t, "LAPTOP", values[2])
+				headers = append(headers, values[1])
+			}
+			assert.Equal(t, tc.expectHeaders, headers)
+		})
+	}
+}
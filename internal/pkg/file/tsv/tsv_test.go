@@ -45,7 +45,7 @@ func TestReadOneLine(t *testing.T) {
 			file := "file1"
 			encoding := "UTF-8"
 			tx := NewTsvFile(file, encoding)
-			tx.Fp = mReadWriteCloser
+			tx.Rc = mReadWriteCloser
 			tx.OpenReadModeInternal()
 			actualData, actualErr := tx.ReadOneLine()
 			if tc.expectErr != nil {
@@ -56,3 +56,37 @@ func TestReadOneLine(t *testing.T) {
 		})
 	}
 }
+
+func TestReadEncoding(t *testing.T) {
+	pdh := [][]string{
+		{"(PDH-TSV 4.0) (東京標準時)(-540)", `\\LAPTOP\Processor(0)\% Processor Time`},
+		{"07/15/2024 14:11:18.978", "9"},
+	}
+	testcases := []struct {
+		pathname   string
+		encoding   string
+		expectData [][]string
+	}{
+		{"testdata/utf16le_bom.tsv", "UTF-16", pdh},
+		{"testdata/utf16le_bom.tsv", "auto", pdh},
+		{"testdata/utf16le.tsv", "auto", pdh},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tx := NewTsvFile(tc.pathname, tc.encoding)
+			err := tx.OpenReadMode()
+			assert.Nil(t, err)
+			defer tx.Close()
+			actualData := [][]string{}
+			for {
+				values, err := tx.ReadOneLine()
+				if err == io.EOF {
+					break
+				}
+				assert.Nil(t, err)
+				actualData = append(actualData, values)
+			}
+			assert.Equal(t, tc.expectData, actualData)
+		})
+	}
+}
@@ -13,8 +13,23 @@
 // limitations under the License.
 package txt
 
-import "github.com/kenita8/errors"
+import "github.com/kenita8/xlcmd/internal/pkg/errors"
 
 var (
 	ErrInternal = errors.New("an internal error has occurred")
 )
+
+// RowError is a record that cannot be read. Reading can go on with the next
+// record. Line is the line of the input where the record starts.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
@@ -48,6 +48,19 @@ type TxtFiler interface {
 	Close()
 }
 
+// LineNumberer is a TxtFiler that knows the line of the input where the last
+// record read starts, which differs from the record count when records span
+// lines or lines are skipped.
+type LineNumberer interface {
+	LineNumber() int
+}
+
+// Namer is a TxtFiler that can be reported by another name than the file it
+// reads, such as "-" for a temporary copy of the standard input.
+type Namer interface {
+	SetName(name string)
+}
+
 type TxtFile struct {
 	Filer    Filer
 	TxtFiler TxtFiler
@@ -57,6 +70,8 @@ type TxtFile struct {
 	Rc       io.ReadCloser
 	Wc       io.WriteCloser
 	scanner  *bufio.Scanner
+	// Name, if not empty, is returned by Filename in place of Pathname.
+	Name string
 }
 
 func NewTxtFile(pathname string, encoding string) *TxtFile {
@@ -78,9 +93,16 @@ func (r *TxtFile) Basename() string {
 }
 
 func (r *TxtFile) Filename() string {
+	if len(r.Name) > 0 {
+		return r.Name
+	}
 	return r.Pathname
 }
 
+func (r *TxtFile) SetName(name string) {
+	r.Name = name
+}
+
 func (r *TxtFile) OpenReadMode() error {
 	fp, err := r.Filer.OpenFile(r.Pathname, os.O_RDONLY, 0)
 	if err != nil {
@@ -96,7 +118,7 @@ func (r *TxtFile) OpenReadMode() error {
 }
 
 func (r *TxtFile) OpenReadModeInternal() error {
-	r.scanner = bufio.NewScanner(r.Fp)
+	r.scanner = bufio.NewScanner(r.Rc)
 	return nil
 }
 
@@ -128,7 +128,7 @@ func TestReadOneLine(t *testing.T) {
 			file := "file1"
 			encoding := "UTF-8"
 			tx := NewTxtFile(file, encoding)
-			tx.Fp = mReadWriteCloser
+			tx.Rc = mReadWriteCloser
 			tx.OpenReadModeInternal()
 			actualData, actualErr := tx.ReadOneLine()
 			if tc.expectErr != nil {
@@ -151,3 +151,34 @@ func TestWriteOneLine(t *testing.T) {
 func TestClose(t *testing.T) {
 
 }
+
+func TestReadEncoding(t *testing.T) {
+	testcases := []struct {
+		pathname   string
+		encoding   string
+		expectData [][]string
+	}{
+		{"testdata/shift_jis.txt", "Shift_JIS", [][]string{{"東京の天気"}, {"晴れ時々曇り"}}},
+		{"testdata/utf16le_bom.txt", "UTF-16", [][]string{{"東京の天気"}, {"晴れ時々曇り"}}},
+		{"testdata/utf16le_bom.txt", "auto", [][]string{{"東京の天気"}, {"晴れ時々曇り"}}},
+		{"testdata/utf8_bom.txt", "auto", [][]string{{"東京の天気"}, {"晴れ時々曇り"}}},
+	}
+	for i, tc := range testcases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tx := NewTxtFile(tc.pathname, tc.encoding)
+			err := tx.OpenReadMode()
+			assert.Nil(t, err)
+			defer tx.Close()
+			actualData := [][]string{}
+			for {
+				values, err := tx.ReadOneLine()
+				if err == io.EOF {
+					break
+				}
+				assert.Nil(t, err)
+				actualData = append(actualData, values)
+			}
+			assert.Equal(t, tc.expectData, actualData)
+		})
+	}
+}